@@ -19,31 +19,41 @@ func getValidMovesByPieceType(pt pieceType, validMoves []potentialMoves) []poten
 
 func getNotationPrefix(src *Square, dest *Square, moves []potentialMoves) string {
 	prefix := src.Piece.Notation
-	fileCount := map[rune]int{}
-	rankCount := map[int]int{}
+	ambiguous := false
+	sameFile := false
+	sameRank := false
 
+	// look for other pieces of the same type that can reach the destination
 	for _, mv := range moves {
+		if mv.origin == src {
+			continue
+		}
+
 		for _, sq := range mv.destinationSquares {
 			if sq == dest {
-				fileCount[mv.origin.File]++
-				rankCount[mv.origin.Rank]++
+				ambiguous = true
+				sameFile = sameFile || mv.origin.File == src.File
+				sameRank = sameRank || mv.origin.Rank == src.Rank
 			}
 		}
 	}
 
-	if len(fileCount) > 1 {
-		prefix += string(src.File)
-	}
-
-	if len(rankCount) > len(fileCount) {
-		prefix += strconv.Itoa(src.Rank)
+	// prefer the file, then the rank, and only use both when neither is unique
+	switch {
+	case !ambiguous:
+		return prefix
+	case !sameFile:
+		return prefix + string(src.File)
+	case !sameRank:
+		return prefix + strconv.Itoa(src.Rank)
+	default:
+		return prefix + string(src.File) + strconv.Itoa(src.Rank)
 	}
-
-	return prefix
 }
 
 func sanitizeNotation(n string, usePGN bool) string {
 	clean := strings.ReplaceAll(n, "!", "")
+	clean = strings.ReplaceAll(clean, "?", "")
 	clean = strings.ReplaceAll(clean, "+", "")
 	clean = strings.ReplaceAll(clean, "#", "")
 	clean = strings.ReplaceAll(clean, "=", "")
@@ -58,23 +68,58 @@ func sanitizeNotation(n string, usePGN bool) string {
 	return clean
 }
 
+// promotionOf returns the promotion piece notation of a move in algebraic
+// notation (e.g. "Q" for "exd8=Q+" or "exd8Q"), or an empty string when the
+// move is not a promotion.
+func promotionOf(ntn string) string {
+	ntn = strings.TrimRight(ntn, "+#")
+	if i := strings.IndexByte(ntn, '='); i >= 0 {
+		return ntn[i+1:]
+	}
+
+	l := len(ntn)
+	if l > 2 && strings.ContainsRune("BNQR", rune(ntn[l-1])) && ntn[l-2] >= '1' && ntn[l-2] <= '8' {
+		return ntn[l-1:]
+	}
+
+	return ""
+}
+
+// toSAN converts a NotatedMoves key into Standard Algebraic Notation, which
+// always uses the letter O for castling.
+func toSAN(key string) string {
+	return strings.ReplaceAll(key, "0", "O")
+}
+
 // AlgebraicClientOptions provides configuration options for an AlgebraicGameClient.
 type AlgebraicClientOptions struct {
-	PGN bool // PGN specifies whether to use PGN-style notation for castling (O-O) instead of (0-0).
+	PGN                     bool // PGN specifies whether to use PGN-style notation for castling (O-O) instead of (0-0).
+	Chess960                bool // Chess960 specifies whether castling follows Fischer Random rules (the king moves onto the castling rook).
+	ShredderFEN             bool // ShredderFEN specifies whether FEN castling availability uses rook files (HAha) instead of KQkq.
+	AllowMovesAfterGameOver bool // AllowMovesAfterGameOver specifies whether Move accepts moves once the game has a result.
 }
 
 // AlgebraicGameClient provides a client for interacting with a chess game using algebraic notation.
 type AlgebraicGameClient struct {
-	fen          string
-	game         *Game
-	isCheck      bool
-	isCheckmate  bool
-	isRepetition bool
-	isStalemate  bool
-	notatedMoves map[string]notationMove
-	options      AlgebraicClientOptions
-	validMoves   []potentialMoves
-	validation   *gameValidator
+	fen                    string
+	game                   *Game
+	isCheck                bool
+	isCheckmate            bool
+	isFiftyMoveDraw        bool
+	isFivefoldRepetition   bool
+	isInsufficientMaterial bool
+	isRepetition           bool
+	isSeventyFiveMoveDraw  bool
+	isStalemate            bool
+	keys                   map[string]string
+	notatedMoves           map[string]notationMove
+	options                AlgebraicClientOptions
+	recorded               *recordedResult
+	redo                   []string
+	results                []*moveResult
+	uciMoves               map[string]string
+	validMoves             []potentialMoves
+	validation             *gameValidator
 
 	events *eventHub
 }
@@ -88,18 +133,20 @@ func CreateAlgebraicGameClient(opts ...AlgebraicClientOptions) *AlgebraicGameCli
 	}
 
 	g := createGame()
+	g.c960 = o.Chess960
+	g.shrd = o.ShredderFEN
 	client := &AlgebraicGameClient{
 		game:         g,
+		keys:         map[string]string{},
 		notatedMoves: map[string]notationMove{},
 		options:      o,
+		uciMoves:     map[string]string{},
 		validation:   CreateGameValidator(g),
 		validMoves:   []potentialMoves{},
 		events:       newEventHub(),
 	}
 	client.bindGameEvents()
-	client.On("undo", func(interface{}) {
-		_ = client.update()
-	})
+	client.On("undo", client.undone)
 	_ = client.update()
 	return client
 }
@@ -142,14 +189,24 @@ func CreateAlgebraicGameClientFromFEN(fen string, opts ...AlgebraicClientOptions
 	g.Board.LastMovedPiece = nil
 	g.hookBoardEvents()
 
-	// track castling availability
+	// track castling availability, switching to Chess960 castling rules when
+	// the king or rooks do not start from their classical squares
 	if len(parts) > 2 {
-		g.cstl = parts[2]
+		if g.cstl, err = parseCastleRights(parts[2], g.Board); err != nil {
+			return nil, err
+		}
 	}
+	g.c960 = o.Chess960 || !g.cstl.isStandard(g.Board)
+	g.shrd = o.ShredderFEN
 
-	// track en-passant square
+	// track en-passant square, treating the pawn that passed over it as the
+	// last piece moved so that the capture is available
 	if len(parts) > 3 {
 		g.enP = g.Board.getSquareByName(parts[3])
+		if p := g.enPassantPawn(); p != nil {
+			p.MoveCount = 1
+			g.Board.LastMovedPiece = p
+		}
 	}
 
 	// track halfmove clock
@@ -162,20 +219,22 @@ func CreateAlgebraicGameClientFromFEN(fen string, opts ...AlgebraicClientOptions
 		g.fmn, _ = strconv.Atoi(parts[5])
 	}
 
+	g.key = newPosition(g).key
+
 	client := &AlgebraicGameClient{
 		fen:          fen,
 		game:         g,
+		keys:         map[string]string{},
 		notatedMoves: map[string]notationMove{},
 		options:      o,
+		uciMoves:     map[string]string{},
 		validation:   CreateGameValidator(g),
 		validMoves:   []potentialMoves{},
 		events:       newEventHub(),
 	}
 
 	client.bindGameEvents()
-	client.On("undo", func(interface{}) {
-		_ = client.update()
-	})
+	client.On("undo", client.undone)
 
 	if err := client.update(); err != nil {
 		return nil, err
@@ -184,6 +243,27 @@ func CreateAlgebraicGameClientFromFEN(fen string, opts ...AlgebraicClientOptions
 	return client, nil
 }
 
+// CreateChess960GameClient creates a new game client with the Chess960 (Fischer
+// Random) starting position for the given identifier, between 0 and 959, using
+// the standard numbering scheme in which 518 is the classical position.
+// It accepts optional AlgebraicClientOptions; Chess960 castling is always used.
+func CreateChess960GameClient(id int, opts ...AlgebraicClientOptions) (*AlgebraicGameClient, error) {
+	rnk, err := chess960Rank(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var o AlgebraicClientOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	o.Chess960 = true
+
+	fen := fmt.Sprintf("%s/pppppppp/8/8/8/8/PPPPPPPP/%s w KQkq - 0 1", strings.ToLower(rnk), rnk)
+
+	return CreateAlgebraicGameClientFromFEN(fen, o)
+}
+
 func (c *AlgebraicGameClient) bindGameEvents() {
 	if c.game == nil {
 		return
@@ -210,6 +290,22 @@ func (c *AlgebraicGameClient) bindGameEvents() {
 	c.game.on("checkmate", func(data interface{}) {
 		c.emit("checkmate", data)
 	})
+
+	c.game.on("repetition", func(data interface{}) {
+		c.emit("repetition", data)
+	})
+
+	c.game.on("fivefoldRepetition", func(data interface{}) {
+		c.emit("fivefoldRepetition", data)
+	})
+
+	c.game.on("fiftyMoveRule", func(data interface{}) {
+		c.emit("fiftyMoveRule", data)
+	})
+
+	c.game.on("seventyFiveMoveRule", func(data interface{}) {
+		c.emit("seventyFiveMoveRule", data)
+	})
 }
 
 func (c *AlgebraicGameClient) emit(ev string, d any) {
@@ -222,6 +318,8 @@ func (c *AlgebraicGameClient) emit(ev string, d any) {
 
 func (c *AlgebraicGameClient) notate(mvs []potentialMoves) map[string]notationMove {
 	algebraic := map[string]notationMove{}
+	pos := newPosition(c.game)
+	legal := pos.LegalMoves(make([]Move, 0, 64))
 
 	for _, vm := range mvs {
 		src := vm.origin
@@ -261,17 +359,13 @@ func (c *AlgebraicGameClient) notate(mvs []potentialMoves) map[string]notationMo
 				}
 			case pieceKing:
 				prefix = src.Piece.Notation
-				if src.File == 'e' && dest.File == 'g' {
+				if wing, ok := castleWing(src, dest); ok {
 					prefix = "0-0"
-					if c.options.PGN {
-						prefix = "O-O"
+					if wing == castleQueenSide {
+						prefix = "0-0-0"
 					}
-					suffix = ""
-				}
-				if src.File == 'e' && dest.File == 'c' {
-					prefix = "0-0-0"
 					if c.options.PGN {
-						prefix = "O-O-O"
+						prefix = sanitizeNotation(prefix, true)
 					}
 					suffix = ""
 				}
@@ -289,14 +383,25 @@ func (c *AlgebraicGameClient) notate(mvs []potentialMoves) map[string]notationMo
 				prefix = src.Piece.Notation
 			}
 
-			if !isPromotion {
-				key := prefix + suffix
-				algebraic[key] = notationMove{Src: src, Dest: dest}
-				continue
+			promos := []string{""}
+			if isPromotion {
+				promos = []string{"R", "N", "B", "Q"}
 			}
 
-			for _, promo := range []string{"R", "N", "B", "Q"} {
-				key := prefix + suffix + promo
+			for _, promo := range promos {
+				key := prefix + suffix
+				if promo != "" {
+					key += "=" + promo
+				}
+
+				isCheck, isCheckmate := c.simulateThreat(pos, legal, src, dest, promo)
+				switch {
+				case isCheckmate:
+					key += "#"
+				case isCheck:
+					key += "+"
+				}
+
 				algebraic[key] = notationMove{Src: src, Dest: dest}
 			}
 		}
@@ -305,6 +410,33 @@ func (c *AlgebraicGameClient) notate(mvs []potentialMoves) map[string]notationMo
 	return algebraic
 }
 
+// simulateThreat plays a move on a copy of the position and reports whether
+// the move leaves the opponent in check and in checkmate.
+func (c *AlgebraicGameClient) simulateThreat(pos *Position, legal []Move, src, dest *Square, promo string) (bool, bool) {
+	from, to := c.game.Board.indexOf(src), c.game.Board.indexOf(dest)
+	p := newPieceFromNotation(promo, pos.side)
+
+	for _, m := range legal {
+		if f, t := pos.squares(m); f != from || t != to {
+			continue
+		}
+
+		if pt, ok := m.Promotion(); ok && (p == nil || pt != p.Type) {
+			continue
+		}
+
+		nxt := *pos
+		nxt.play(m)
+		if !nxt.InCheck() {
+			return false, false
+		}
+
+		return true, !nxt.hasLegalMove()
+	}
+
+	return false, false
+}
+
 func (c *AlgebraicGameClient) update() error {
 	result, err := c.validation.Check()
 	if err != nil {
@@ -312,10 +444,21 @@ func (c *AlgebraicGameClient) update() error {
 	}
 	c.isCheck = result.IsCheck
 	c.isCheckmate = result.IsCheckmate
+	c.isFiftyMoveDraw = result.IsFiftyMoveDraw
+	c.isFivefoldRepetition = result.IsFivefoldRepetition
+	c.isInsufficientMaterial = result.IsInsufficientMaterial
 	c.isRepetition = result.IsRepetition
+	c.isSeventyFiveMoveDraw = result.IsSeventyFiveMoveDraw
 	c.isStalemate = result.IsStalemate
 	c.validMoves = result.ValidMoves
+	c.updateResult()
 	c.notatedMoves = c.notate(result.ValidMoves)
+	c.keys = map[string]string{}
+	c.uciMoves = map[string]string{}
+	for key := range c.notatedMoves {
+		c.keys[sanitizeNotation(key, c.options.PGN)] = key
+		c.uciMoves[c.uciFromKey(key)] = key
+	}
 	return nil
 }
 
@@ -329,83 +472,179 @@ func (c *AlgebraicGameClient) FEN() string {
 	return c.game.fen()
 }
 
+// Hash returns the 64-bit Zobrist key of the current position. Positions that
+// are the same under the FIDE repetition rules share a key, making it
+// suitable for detecting repetitions, transposition tables and deduplicating
+// positions across games.
+func (c *AlgebraicGameClient) Hash() uint64 {
+	return c.game.Hash()
+}
+
+// Position returns the current position for low-level move generation. Moves
+// made on the position are independent of the client and its game.
+func (c *AlgebraicGameClient) Position() *Position {
+	return newPosition(c.game)
+}
+
+// AgreeDraw ends the game as a draw agreed by both players.
+func (c *AlgebraicGameClient) AgreeDraw() error {
+	return c.end(ResultDraw, TerminationAgreement)
+}
+
+// ClaimDraw ends the game as a draw when the current position has occurred
+// three times or fifty moves have been played without a capture or pawn move.
+// It returns an error when no draw can be claimed.
+func (c *AlgebraicGameClient) ClaimDraw() error {
+	switch {
+	case c.isRepetition:
+		return c.end(ResultDraw, TerminationThreefoldRepetition)
+	case c.isFiftyMoveDraw:
+		return c.end(ResultDraw, TerminationFiftyMoveRule)
+	default:
+		return errors.New("no draw can be claimed in the current position")
+	}
+}
+
+// Resign ends the game with a win for the opponent of the given side.
+func (c *AlgebraicGameClient) Resign(sd Side) error {
+	return c.end(winFor(sd.Opponent()), TerminationResignation)
+}
+
+// Timeout ends the game when the given side has run out of time. The
+// opponent wins unless they have insufficient material to checkmate, in
+// which case the game is drawn.
+func (c *AlgebraicGameClient) Timeout(sd Side) error {
+	if c.HasInsufficientMaterial(sd.Opponent()) {
+		return c.end(ResultDraw, TerminationTimeout)
+	}
+
+	return c.end(winFor(sd.Opponent()), TerminationTimeout)
+}
+
+// end records the result of the game and notifies "gameOver" subscribers.
+// It returns an error when the game has already ended.
+func (c *AlgebraicGameClient) end(res Result, term Termination) error {
+	if c.game.res != ResultOngoing {
+		return fmt.Errorf("%w (%s)", ErrGameOver, c.game.term)
+	}
+
+	c.game.res = res
+	c.game.term = term
+	if !term.isAutomatic() {
+		c.recorded = newRecordedResult(res, term, c.game.MoveHistory)
+	}
+	c.emit("gameOver", &GameOverEvent{Result: res, Termination: term})
+
+	return nil
+}
+
+// updateResult ends the game when the current position is checkmate,
+// stalemate or an automatic draw. The result is cleared first, so that
+// undoing a move re-opens the game. Results recorded by the players
+// (resignation, agreement, timeout or a claimed draw) are restored while the
+// moves they were recorded after are still played, so that they are kept
+// when moving on and when replaying an undone move.
+func (c *AlgebraicGameClient) updateResult() {
+	c.game.res = ResultOngoing
+	c.game.term = TerminationNone
+
+	if c.recorded.holds(c.game.MoveHistory) {
+		c.game.res = c.recorded.res
+		c.game.term = c.recorded.term
+		return
+	}
+
+	switch {
+	case c.isCheckmate:
+		_ = c.end(winFor(c.game.getCurrentSide().Opponent()), TerminationCheckmate)
+	case c.isStalemate:
+		_ = c.end(ResultDraw, TerminationStalemate)
+	case c.isInsufficientMaterial:
+		_ = c.end(ResultDraw, TerminationInsufficientMaterial)
+	case c.isFivefoldRepetition:
+		_ = c.end(ResultDraw, TerminationFivefoldRepetition)
+	case c.isSeventyFiveMoveDraw:
+		_ = c.end(ResultDraw, TerminationSeventyFiveMoveRule)
+	}
+}
+
+// HasInsufficientMaterial reports whether the given side (White or Black)
+// lacks the material to checkmate its opponent by any sequence of legal moves.
+// When a player runs out of time and their opponent has insufficient material,
+// the game is drawn rather than lost.
+func (c *AlgebraicGameClient) HasInsufficientMaterial(sd Side) bool {
+	return c.validation.hasInsufficientMaterial(sd)
+}
+
 // Move attempts to make a move using algebraic notation.
 func (c *AlgebraicGameClient) Move(ntn string) (*moveResult, error) {
 	if ntn == "" {
 		return nil, errors.New("notation is invalid")
 	}
 
-	origNtn := ntn
-	ntn = sanitizeNotation(ntn, c.options.PGN)
+	if c.game.res != ResultOngoing && !c.options.AllowMovesAfterGameOver {
+		return nil, fmt.Errorf("%w (%s)", ErrGameOver, c.game.term)
+	}
 
-	var prmP string
-	if len(ntn) > 0 {
-		p := ntn[len(ntn)-1]
-		if strings.ContainsRune("BNQR", rune(p)) {
-			prmP = string(p)
-		}
+	res, err := c.play(ntn)
+	if err != nil {
+		return nil, err
 	}
 
-	// Fallback for verbose notations like "Nb1c3" when "Nc3" is expected.
-	// If the direct lookup fails, try to parse it.
-	if _, ok := c.notatedMoves[ntn]; !ok && len(ntn) >= 4 {
-		// A piece notation (e.g., N, B, R, Q, K) is one character.
-		// A pawn move would be something like e2e4, which is also 4 characters.
-		p := ""
-		ofs := 0
-		if strings.ContainsRune("NBRQK", rune(ntn[0])) {
-			p = string(ntn[0])
-			ofs = 1
-		}
+	// playing the next undone move keeps the remaining moves available to
+	// Redo, while any other move discards them
+	if n := len(c.redo); n > 0 && c.redo[n-1] == res.Move.Algebraic {
+		c.redo = c.redo[:n-1]
+	} else {
+		c.redo = nil
+	}
 
-		if len(ntn) >= ofs+4 {
-			srcN := ntn[ofs : ofs+2]
-			dstN := ntn[ofs+2 : ofs+4]
+	return res, nil
+}
 
-			// Find the corresponding standard notation move
-			for k, mv := range c.notatedMoves {
-				if mv.Src.name() == srcN && mv.Dest.name() == dstN && strings.HasPrefix(k, p) {
-					ntn = k // Found it, use the standard notation
-					break
-				}
-			}
-		}
+// lookup resolves a move in algebraic or coordinate notation into its
+// NotatedMoves lookup key. The move is looked up without check, promotion or
+// annotation symbols, falling back to coordinate notation (e.g. "e2e4",
+// "Nb1c3" or "e7e8q").
+func (c *AlgebraicGameClient) lookup(ntn string) (string, bool) {
+	clean := sanitizeNotation(ntn, c.options.PGN)
+	if key, ok := c.keys[clean]; ok {
+		return key, true
 	}
 
-	if mv, ok := c.notatedMoves[ntn]; ok {
-		res, err := c.game.move(mv.Src, mv.Dest, ntn)
-		if err != nil {
-			return nil, err
-		}
+	return c.lookupUCI(clean)
+}
 
-		if prmP != "" {
-			var p *Piece
-			side := c.game.getCurrentSide().Opponent()
-			switch prmP {
-			case "B":
-				p = newPiece(pieceBishop, side)
-			case "N":
-				p = newPiece(pieceKnight, side)
-			case "Q":
-				p = newPiece(pieceQueen, side)
-			case "R":
-				p = newPiece(pieceRook, side)
-			}
-			if p != nil {
-				if _, err := c.game.promote(res.Move.PostSquare, p); err != nil {
-					return nil, err
-				}
+// play makes a move using algebraic or coordinate notation and records it on
+// the client's move stack.
+func (c *AlgebraicGameClient) play(ntn string) (*moveResult, error) {
+	key, ok := c.lookup(ntn)
+	if !ok {
+		return nil, fmt.Errorf("notation is invalid (%s)", ntn)
+	}
+
+	mv := c.notatedMoves[key]
+	res, err := c.game.move(mv.Src, mv.Dest, toSAN(key))
+	if err != nil {
+		return nil, err
+	}
+
+	if prmP := promotionOf(key); prmP != "" {
+		p := newPieceFromNotation(prmP, c.game.getCurrentSide().Opponent())
+		if p != nil {
+			if _, err := c.game.promote(res.Move.PostSquare, p); err != nil {
+				return nil, err
 			}
 		}
+	}
 
-		if err := c.update(); err != nil {
-			return nil, err
-		}
+	c.results = append(c.results, res)
 
-		return res, nil
+	if err := c.update(); err != nil {
+		return nil, err
 	}
 
-	return nil, fmt.Errorf("notation is invalid (%s)", origNtn)
+	return res, nil
 }
 
 // On registers an event handler for the given event.
@@ -418,6 +657,11 @@ func (c *AlgebraicGameClient) Move(ntn string) (*moveResult, error) {
 //   - "undo":      emitted after a move has been undone. The handler receives the undone *MoveEvent.
 //   - "check":     emitted when a player is put in check. The handler receives a *KingThreatEvent.
 //   - "checkmate": emitted when a player is checkmated. The handler receives a *KingThreatEvent.
+//   - "repetition": emitted when the position has occurred three times. The handler receives a *RepetitionEvent.
+//   - "fivefoldRepetition": emitted when the position has occurred five times. The handler receives a *RepetitionEvent.
+//   - "fiftyMoveRule": emitted when a draw may be claimed under the fifty-move rule. The handler receives a *MoveRuleEvent.
+//   - "seventyFiveMoveRule": emitted when the game is drawn under the seventy-five-move rule. The handler receives a *MoveRuleEvent.
+//   - "gameOver":  emitted when the game ends. The handler receives a *GameOverEvent.
 func (c *AlgebraicGameClient) On(ev string, hndlr func(any)) {
 	if c == nil {
 		return
@@ -436,12 +680,18 @@ func (c *AlgebraicGameClient) Status(frc ...bool) (*GameStatus, error) {
 	}
 
 	status := &GameStatus{
-		Game:         c.game,
-		IsCheck:      c.isCheck,
-		IsCheckmate:  c.isCheckmate,
-		IsRepetition: c.isRepetition,
-		IsStalemate:  c.isStalemate,
-		NotatedMoves: c.notatedMoves,
+		Game:                   c.game,
+		IsCheck:                c.isCheck,
+		IsCheckmate:            c.isCheckmate,
+		IsFiftyMoveDraw:        c.isFiftyMoveDraw,
+		IsFivefoldRepetition:   c.isFivefoldRepetition,
+		IsInsufficientMaterial: c.isInsufficientMaterial,
+		IsRepetition:           c.isRepetition,
+		IsSeventyFiveMoveDraw:  c.isSeventyFiveMoveDraw,
+		IsStalemate:            c.isStalemate,
+		NotatedMoves:           c.notatedMoves,
+		Result:                 c.game.res,
+		Termination:            c.game.term,
 	}
 
 	return status, nil
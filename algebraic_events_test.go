@@ -1,6 +1,10 @@
 package chess
 
-import "testing"
+import (
+	"runtime"
+	"slices"
+	"testing"
+)
 
 func TestMoveEventTriggered(t *testing.T) {
 	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
@@ -131,3 +135,33 @@ func TestIssue53EnPassantEvent(t *testing.T) {
 		t.Fatalf("expected en passant event")
 	}
 }
+
+func TestEventHandlersRunInOrderBeforeMoveReturns(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+	var calls []int
+
+	for i := range 3 {
+		client.On("move", func(interface{}) {
+			calls = append(calls, i)
+		})
+	}
+
+	mustMove(t, client, "e4")
+
+	if !slices.Equal(calls, []int{0, 1, 2}) {
+		t.Fatalf("expected handlers 0, 1 and 2 to run before Move returned, got %v", calls)
+	}
+}
+
+func TestEventHandlersStartNoGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	for range 100 {
+		client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+		client.On("move", func(interface{}) {})
+	}
+
+	if after := runtime.NumGoroutine(); after > before+10 {
+		t.Fatalf("expected clients not to start goroutines, got %d more", after-before)
+	}
+}
@@ -19,3 +19,12 @@ func mustStatus(t *testing.T, client *AlgebraicGameClient, force bool) *GameStat
 	}
 	return sts
 }
+
+func mustFEN(t *testing.T, fen string, opts ...AlgebraicClientOptions) *AlgebraicGameClient {
+	t.Helper()
+	client, err := CreateAlgebraicGameClientFromFEN(fen, opts...)
+	if err != nil {
+		t.Fatalf("failed to load FEN %s: %v", fen, err)
+	}
+	return client
+}
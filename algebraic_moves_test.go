@@ -150,3 +150,62 @@ func TestVerboseNotationParses(t *testing.T) {
 		t.Fatalf("expected knight piece")
 	}
 }
+
+func TestNotatedMovesIncludeCheckSuffixes(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+
+	for _, mv := range []string{"e4", "f5", "d4", "g5"} {
+		mustMove(t, client, mv)
+	}
+
+	status := mustStatus(t, client, false)
+	if _, ok := status.NotatedMoves["Qh5#"]; !ok {
+		t.Fatalf("expected Qh5# in notated moves")
+	}
+	if _, ok := status.NotatedMoves["Bb5"]; !ok {
+		t.Fatalf("expected Bb5 in notated moves")
+	}
+
+	res := mustMove(t, client, "Qh5")
+	if res.Move.Algebraic != "Qh5#" {
+		t.Fatalf("expected move recorded as Qh5#, got %s", res.Move.Algebraic)
+	}
+}
+
+func TestMoveAcceptsSANVariants(t *testing.T) {
+	for _, ntn := range []string{"Bb5+", "Bb5", "Bb5+!?", "Bf1b5"} {
+		client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+
+		for _, mv := range []string{"e4", "d6"} {
+			mustMove(t, client, mv)
+		}
+
+		res := mustMove(t, client, ntn)
+		if res.Move.Algebraic != "Bb5+" {
+			t.Fatalf("expected %s recorded as Bb5+, got %s", ntn, res.Move.Algebraic)
+		}
+	}
+}
+
+func TestQueenDisambiguationFileAndRank(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN("8/7k/8/8/8/Q7/8/Q1Q4K w - - 0 1")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	for san, uci := range map[string]string{
+		"Qa1b2": "a1b2",
+		"Q3b2":  "a3b2",
+		"Qcb2":  "c1b2",
+		"Q1a2":  "a1a2",
+		"Q3a2":  "a3a2",
+	} {
+		got, err := client.SANToUCI(san)
+		if err != nil {
+			t.Fatalf("SANToUCI(%s) error = %v", san, err)
+		}
+		if got != uci {
+			t.Fatalf("SANToUCI(%s) = %s, want %s", san, got, uci)
+		}
+	}
+}
@@ -17,7 +17,7 @@ func TestWhitePawnPromotionMoves(t *testing.T) {
 		t.Fatalf("expected base move to require promotion")
 	}
 
-	for _, move := range []string{"a8R", "a8N", "a8B", "a8Q"} {
+	for _, move := range []string{"a8=R", "a8=N", "a8=B", "a8=Q"} {
 		if _, ok := status.NotatedMoves[move]; !ok {
 			t.Fatalf("expected promotion move %s", move)
 		}
@@ -41,7 +41,7 @@ func TestBlackPawnPromotionMoves(t *testing.T) {
 		t.Fatalf("expected base move to require promotion")
 	}
 
-	for _, move := range []string{"a1R", "a1N", "a1B", "a1Q"} {
+	for _, move := range []string{"a1=R", "a1=N", "a1=B", "a1=Q"} {
 		if _, ok := status.NotatedMoves[move]; !ok {
 			t.Fatalf("expected promotion move %s", move)
 		}
@@ -111,12 +111,12 @@ func TestIssue17PromotionAvailability(t *testing.T) {
 
 	status := mustStatus(t, client, true)
 
-	for _, mv := range []string{"cxb8R", "cxb8N", "cxb8B", "cxb8Q", "cxd8R", "cxd8N", "cxd8B", "cxd8Q"} {
+	for _, mv := range []string{"cxb8=R", "cxb8=N", "cxb8=B", "cxb8=Q", "cxd8=R+", "cxd8=N", "cxd8=B", "cxd8=Q+"} {
 		if _, ok := status.NotatedMoves[mv]; !ok {
 			t.Fatalf("expected promotion move %s", mv)
 		}
 	}
-	if _, ok := status.NotatedMoves["Bxg8R"]; ok {
+	if _, ok := status.NotatedMoves["Bxg8=R"]; ok {
 		t.Fatalf("bishop should not have promotion move")
 	}
 }
@@ -134,7 +134,7 @@ func TestIssue18PromotionAvailability(t *testing.T) {
 	if _, ok := status.NotatedMoves["cxb8"]; ok {
 		t.Fatalf("expected base move to require promotion")
 	}
-	for _, mv := range []string{"cxb8Q", "cxb8R", "cxb8B", "cxb8N"} {
+	for _, mv := range []string{"cxb8=Q", "cxb8=R", "cxb8=B", "cxb8=N"} {
 		if _, ok := status.NotatedMoves[mv]; !ok {
 			t.Fatalf("expected promotion %s", mv)
 		}
@@ -135,3 +135,18 @@ func TestIssue43ParseGxf3Check(t *testing.T) {
 		t.Fatalf("expected checkmate true")
 	}
 }
+
+func TestEnPassantRequiresDoubleStep(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN("4k3/8/4p3/3P4/8/8/8/4K3 b - - 0 1")
+	if err != nil {
+		t.Fatalf("failed to load FEN: %v", err)
+	}
+
+	// the pawn's first move is a single step, so it cannot be captured en passant
+	mustMove(t, client, "e5")
+
+	status := mustStatus(t, client, false)
+	if _, ok := status.NotatedMoves["dxe6"]; ok {
+		t.Fatalf("expected no en passant capture after a single step")
+	}
+}
@@ -65,3 +65,100 @@ func TestIssue77UndoFirstMove(t *testing.T) {
 		t.Fatalf("expected e4 available after undo")
 	}
 }
+
+func TestFiftyMoveRuleFromFEN(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN("4k3/8/8/8/8/8/8/R3K3 w - - 99 80")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	status := mustStatus(t, client, false)
+	if status.IsFiftyMoveDraw || status.IsSeventyFiveMoveDraw {
+		t.Fatalf("expected no move rule draw at halfmove clock 99")
+	}
+
+	var events []*MoveRuleEvent
+	client.On("fiftyMoveRule", func(data interface{}) {
+		if ev, ok := data.(*MoveRuleEvent); ok {
+			events = append(events, ev)
+		}
+	})
+
+	mustMove(t, client, "Ra2")
+
+	status = mustStatus(t, client, false)
+	if !status.IsFiftyMoveDraw {
+		t.Fatalf("expected fifty-move draw to be claimable")
+	}
+	if status.IsSeventyFiveMoveDraw {
+		t.Fatalf("expected IsSeventyFiveMoveDraw false")
+	}
+	if len(events) != 1 || events[0].HalfmoveClock != 100 {
+		t.Fatalf("expected a fiftyMoveRule event at halfmove clock 100, got %v", events)
+	}
+
+	mustMove(t, client, "Kd7")
+	mustMove(t, client, "Ra7+")
+	mustMove(t, client, "Kd6")
+
+	if !mustStatus(t, client, false).IsFiftyMoveDraw {
+		t.Fatalf("expected fifty-move draw to remain claimable")
+	}
+}
+
+func TestFiftyMoveRuleResetByPawnMoveAndCapture(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN("4k3/3p4/8/8/8/8/4P3/R3K3 w - - 100 80")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	if !mustStatus(t, client, false).IsFiftyMoveDraw {
+		t.Fatalf("expected fifty-move draw to be claimable from FEN")
+	}
+
+	mustMove(t, client, "e4")
+	if mustStatus(t, client, false).IsFiftyMoveDraw {
+		t.Fatalf("expected pawn move to reset the halfmove clock")
+	}
+}
+
+func TestSeventyFiveMoveRule(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN("4k3/8/8/8/8/8/8/R3K3 w - - 149 100")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	triggered := false
+	client.On("seventyFiveMoveRule", func(data interface{}) {
+		if ev, ok := data.(*MoveRuleEvent); ok && ev.HalfmoveClock == 150 {
+			triggered = true
+		}
+	})
+
+	mustMove(t, client, "Rb1")
+
+	status := mustStatus(t, client, false)
+	if !status.IsSeventyFiveMoveDraw || !status.IsFiftyMoveDraw {
+		t.Fatalf("expected seventy-five-move draw, got %#v", status)
+	}
+	if !triggered {
+		t.Fatalf("expected seventyFiveMoveRule event")
+	}
+}
+
+func TestCheckmateTakesPrecedenceOverMoveRules(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN("k7/8/1K6/8/8/8/8/7R w - - 149 100")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	mustMove(t, client, "Rh8#")
+
+	status := mustStatus(t, client, false)
+	if !status.IsCheckmate {
+		t.Fatalf("expected checkmate")
+	}
+	if status.IsFiftyMoveDraw || status.IsSeventyFiveMoveDraw {
+		t.Fatalf("expected checkmate to take precedence over the move rules")
+	}
+}
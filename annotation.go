@@ -0,0 +1,165 @@
+package chess
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Annotation holds the commentary attached to a move, as found in PGN:
+// comments, numeric annotation glyphs (NAGs) and embedded commands such as
+// [%clk 0:03:00] or [%eval 0.31].
+type Annotation struct {
+	// Commands contains the embedded commands of the comments, keyed by name
+	// without the "%" (e.g. "clk", "eval", "cal" or "csl").
+	Commands map[string]string
+	// Comments are the comments following the move, with embedded commands removed.
+	Comments []string
+	// NAGs are the numeric annotation glyphs of the move (e.g. 1 for "!" or 6 for "?!").
+	NAGs []int
+	// StartingComments are the comments preceding the first move of a variation.
+	StartingComments []string
+}
+
+// pgnCommandPattern matches an embedded command within a PGN comment.
+var pgnCommandPattern = regexp.MustCompile(`\[%(\w+)\s+([^\]]*)\]`)
+
+// pgnSuffixNAGs maps move suffix annotations to their numeric glyphs.
+var pgnSuffixNAGs = map[string]int{
+	"!":  1,
+	"?":  2,
+	"!!": 3,
+	"??": 4,
+	"!?": 5,
+	"?!": 6,
+}
+
+// hasComments reports whether comments (or embedded commands) follow the move.
+func (a *Annotation) hasComments() bool {
+	return a != nil && (len(a.Comments) > 0 || len(a.Commands) > 0)
+}
+
+// addComment records a PGN comment, extracting any embedded commands.
+func (a *Annotation) addComment(cmt string) {
+	for _, m := range pgnCommandPattern.FindAllStringSubmatch(cmt, -1) {
+		if a.Commands == nil {
+			a.Commands = map[string]string{}
+		}
+		a.Commands[m[1]] = strings.TrimSpace(m[2])
+	}
+
+	cmt = strings.Join(strings.Fields(pgnCommandPattern.ReplaceAllString(cmt, " ")), " ")
+	if cmt != "" {
+		a.Comments = append(a.Comments, cmt)
+	}
+}
+
+// addNAG records a numeric annotation glyph ($1) or suffix annotation (!?).
+func (a *Annotation) addNAG(nag string) {
+	if n, ok := pgnSuffixNAGs[nag]; ok {
+		a.NAGs = append(a.NAGs, n)
+		return
+	}
+
+	if n, err := strconv.Atoi(strings.TrimPrefix(nag, "$")); err == nil {
+		a.NAGs = append(a.NAGs, n)
+	}
+}
+
+// commandText returns the embedded commands in PGN form, sorted by name.
+func (a *Annotation) commandText() string {
+	names := make([]string, 0, len(a.Commands))
+	for name := range a.Commands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	cmds := make([]string, 0, len(names))
+	for _, name := range names {
+		cmds = append(cmds, fmt.Sprintf("[%%%s %s]", name, a.Commands[name]))
+	}
+
+	return strings.Join(cmds, " ")
+}
+
+// Clock returns the remaining time recorded by the [%clk] command.
+func (a *Annotation) Clock() (time.Duration, bool) {
+	if a == nil {
+		return 0, false
+	}
+
+	clk, ok := a.Commands["clk"]
+	if !ok {
+		return 0, false
+	}
+
+	parts := strings.Split(clk, ":")
+	if len(parts) > 3 {
+		return 0, false
+	}
+
+	var d time.Duration
+	for _, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil || v < 0 {
+			return 0, false
+		}
+		d = d*60 + time.Duration(v*float64(time.Second))
+	}
+
+	return d, true
+}
+
+// Eval returns the engine evaluation recorded by the [%eval] command, either
+// in pawns from white's point of view or, for forced mates (e.g. "#-3"), as
+// the number of moves to mate (negative when black mates).
+func (a *Annotation) Eval() (pawns float64, mate int, ok bool) {
+	if a == nil {
+		return 0, 0, false
+	}
+
+	ev, found := a.Commands["eval"]
+	if !found {
+		return 0, 0, false
+	}
+
+	// the evaluation may be followed by the search depth (e.g. "0.31,20")
+	ev, _, _ = strings.Cut(ev, ",")
+
+	if m, isMate := strings.CutPrefix(ev, "#"); isMate {
+		n, err := strconv.Atoi(m)
+		return 0, n, err == nil
+	}
+
+	p, err := strconv.ParseFloat(ev, 64)
+	return p, 0, err == nil
+}
+
+// Arrows returns the arrows drawn by the [%cal] command (e.g. "Ge2e4").
+func (a *Annotation) Arrows() []string {
+	return a.commandList("cal")
+}
+
+// Highlights returns the squares highlighted by the [%csl] command (e.g. "Rd5").
+func (a *Annotation) Highlights() []string {
+	return a.commandList("csl")
+}
+
+// commandList splits a comma separated command value.
+func (a *Annotation) commandList(name string) []string {
+	if a == nil || a.Commands[name] == "" {
+		return nil
+	}
+
+	var vals []string
+	for _, v := range strings.Split(a.Commands[name], ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			vals = append(vals, v)
+		}
+	}
+
+	return vals
+}
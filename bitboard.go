@@ -0,0 +1,156 @@
+package chess
+
+import "math/bits"
+
+// bitboard is a set of squares with one bit per square. Bits are ordered as
+// Board.Squares: bit 0 is a1, bit 7 is h1, bit 8 is a2 and bit 63 is h8.
+type bitboard uint64
+
+const (
+	fileA bitboard = 0x0101010101010101 // fileA contains every square of the a-file.
+	rank1 bitboard = 0xff               // rank1 contains every square of the first rank.
+	rank8 bitboard = rank1 << 56        // rank8 contains every square of the eighth rank.
+)
+
+// ray directions, those that increase the square index come first
+const (
+	rayNorth = iota
+	rayEast
+	rayNorthEast
+	rayNorthWest
+	raySouth
+	rayWest
+	raySouthEast
+	raySouthWest
+)
+
+var (
+	// kingAttacks contains the squares a king attacks from each square.
+	kingAttacks [64]bitboard
+	// knightAttacks contains the squares a knight attacks from each square.
+	knightAttacks [64]bitboard
+	// pawnAttacks contains the squares a pawn of each side attacks from each square.
+	pawnAttacks [2][64]bitboard
+	// rays contains, for each direction, the squares from each square to the edge of the board.
+	rays [8][64]bitboard
+)
+
+func init() {
+	rayDeltas := [8][2]int{
+		rayNorth:     {0, 1},
+		rayEast:      {1, 0},
+		rayNorthEast: {1, 1},
+		rayNorthWest: {-1, 1},
+		raySouth:     {0, -1},
+		rayWest:      {-1, 0},
+		raySouthEast: {1, -1},
+		raySouthWest: {-1, -1},
+	}
+
+	knightDeltas := [8][2]int{
+		{1, 2}, {2, 1}, {2, -1}, {1, -2},
+		{-1, -2}, {-2, -1}, {-2, 1}, {-1, 2},
+	}
+
+	for sq := range 64 {
+		f, r := sq%8, sq/8
+
+		// target returns the square offset from sq, or -1 when it is off the board
+		target := func(df, dr int) int {
+			if f+df < 0 || f+df > 7 || r+dr < 0 || r+dr > 7 {
+				return -1
+			}
+
+			return (r+dr)*8 + f + df
+		}
+
+		for dir, d := range rayDeltas {
+			if t := target(d[0], d[1]); t >= 0 {
+				kingAttacks[sq] |= squareBit(t)
+			}
+
+			for n := 1; ; n++ {
+				t := target(d[0]*n, d[1]*n)
+				if t < 0 {
+					break
+				}
+				rays[dir][sq] |= squareBit(t)
+			}
+		}
+
+		for _, d := range knightDeltas {
+			if t := target(d[0], d[1]); t >= 0 {
+				knightAttacks[sq] |= squareBit(t)
+			}
+		}
+
+		for _, df := range []int{-1, 1} {
+			if t := target(df, 1); t >= 0 {
+				pawnAttacks[sideWhite][sq] |= squareBit(t)
+			}
+
+			if t := target(df, -1); t >= 0 {
+				pawnAttacks[sideBlack][sq] |= squareBit(t)
+			}
+		}
+	}
+}
+
+// squareBit returns the bitboard containing only the given square.
+func squareBit(sq int) bitboard {
+	return 1 << uint(sq)
+}
+
+// first returns the lowest square in the set.
+func (bb bitboard) first() int {
+	return bits.TrailingZeros64(uint64(bb))
+}
+
+// count returns the number of squares in the set.
+func (bb bitboard) count() int {
+	return bits.OnesCount64(uint64(bb))
+}
+
+// has reports whether the square is in the set.
+func (bb bitboard) has(sq int) bool {
+	return bb&squareBit(sq) != 0
+}
+
+// pop removes and returns the lowest square in the set.
+func (bb *bitboard) pop() int {
+	sq := bits.TrailingZeros64(uint64(*bb))
+	*bb &= *bb - 1
+
+	return sq
+}
+
+// rayAttacks returns the squares attacked along a ray from sq, up to and
+// including the first occupied square.
+func rayAttacks(dir, sq int, occ bitboard) bitboard {
+	att := rays[dir][sq]
+	if blk := att & occ; blk != 0 {
+		b := blk.first()
+		if dir >= raySouth {
+			b = 63 - bits.LeadingZeros64(uint64(blk))
+		}
+		att ^= rays[dir][b]
+	}
+
+	return att
+}
+
+// bishopAttacks returns the squares a bishop on sq attacks given the occupied squares.
+func bishopAttacks(sq int, occ bitboard) bitboard {
+	return rayAttacks(rayNorthEast, sq, occ) |
+		rayAttacks(rayNorthWest, sq, occ) |
+		rayAttacks(raySouthEast, sq, occ) |
+		rayAttacks(raySouthWest, sq, occ)
+}
+
+// rookAttacks returns the squares a rook on sq attacks given the occupied squares.
+func rookAttacks(sq int, occ bitboard) bitboard {
+	return rayAttacks(rayNorth, sq, occ) |
+		rayAttacks(rayEast, sq, occ) |
+		rayAttacks(raySouth, sq, occ) |
+		rayAttacks(rayWest, sq, occ)
+}
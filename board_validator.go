@@ -1,118 +1,132 @@
 package chess
 
-import "errors"
+import (
+	"errors"
+	"slices"
+)
 
 type boardValidator struct {
 	game  *Game
 	board *Board
+	pos   *Position
+	side  Side
 }
 
 func CreateBoardValidator(g *Game) *boardValidator {
 	return &boardValidator{
 		game:  g,
 		board: g.Board,
+		side:  g.getCurrentSide(),
 	}
 }
 
-func (v *boardValidator) evaluateCastle(validMoves []potentialMoves) {
-	getValidSquares := func(src *Square) []*Square {
-		for _, vm := range validMoves {
-			if vm.origin == src {
-				return vm.destinationSquares
-			}
-		}
-		return nil
+// evaluateCastle adds castling moves for the validator's side to the valid
+// moves. Each remaining castling right is checked for a king and rook on the
+// back rank, empty squares between them and their destinations, and a king
+// that is neither in check nor passes through an attacked square. Chess960
+// castling moves use the rook's square as the king's destination.
+func (v *boardValidator) evaluateCastle(validMoves []potentialMoves) []potentialMoves {
+	kingSquare := findBackRankKing(v.board, v.side)
+	if kingSquare == nil || v.isSquareAttacked(kingSquare) {
+		return validMoves
 	}
 
-	rank := 1
-	if v.game.getCurrentSide() == sideBlack {
-		rank = 8
-	}
+	king := kingSquare.Piece
+	rank := backRank(v.side)
 
-	squares := map[rune]*Square{
-		'a': v.board.GetSquare('a', rank),
-		'b': v.board.GetSquare('b', rank),
-		'c': v.board.GetSquare('c', rank),
-		'd': v.board.GetSquare('d', rank),
-		'e': v.board.GetSquare('e', rank),
-		'f': v.board.GetSquare('f', rank),
-		'g': v.board.GetSquare('g', rank),
-		'h': v.board.GetSquare('h', rank),
-	}
+	// isSafe checks whether the king would be attacked on the given square
+	isSafe := func(sq *Square) bool {
+		if sq == kingSquare {
+			return true
+		}
 
-	kingSquare := squares['e']
-	if kingSquare == nil || kingSquare.Piece == nil {
-		return
-	}
-	if kingSquare.Piece.Type != pieceKing || kingSquare.Piece.MoveCount != 0 {
-		return
-	}
-	if v.isSquareAttacked(kingSquare) {
-		return
+		orig := sq.Piece
+		kingSquare.Piece = nil
+		sq.Piece = king
+		safe := !v.isSquareAttacked(sq)
+		sq.Piece = orig
+		kingSquare.Piece = king
+
+		return safe
 	}
 
-	// queen side
-	if squares['a'] != nil &&
-		squares['a'].Piece != nil &&
-		squares['a'].Piece.Type == pieceRook &&
-		squares['a'].Piece.MoveCount == 0 {
-
-		if squares['b'].Piece == nil &&
-			squares['c'].Piece == nil &&
-			squares['d'].Piece == nil {
-
-			res1, _ := v.board.Move(squares['e'], squares['d'], true, "")
-			canStepThroughD := !v.isSquareAttacked(squares['d'])
-			res1.Undo()
-			if canStepThroughD {
-				res2, _ := v.board.Move(squares['e'], squares['c'], true, "")
-				canLandOnC := !v.isSquareAttacked(squares['c'])
-				res2.Undo()
-				if canLandOnC {
-					moveSquares := getValidSquares(squares['e'])
-					if moveSquares != nil {
-						moveSquares = append(moveSquares, squares['c'])
-						for idx := range validMoves {
-							if validMoves[idx].origin == squares['e'] {
-								validMoves[idx].destinationSquares = moveSquares
-							}
-						}
-					}
-				}
+	for _, wing := range []int{castleKingSide, castleQueenSide} {
+		rf := v.game.cstl[v.side][wing]
+		if rf == 0 {
+			continue
+		}
+
+		rookSquare := v.board.GetSquare(rf, rank)
+		if rookSquare.Piece == nil ||
+			rookSquare.Piece.Type != pieceRook ||
+			rookSquare.Piece.Side != v.side {
+			continue
+		}
+
+		// classical castling is only available from the e-file with the corner rooks
+		if !v.game.c960 && (kingSquare.File != 'e' || (rf != 'a' && rf != 'h')) {
+			continue
+		}
+
+		kf, krf := castleDestinations(wing)
+		lo := min(kingSquare.File, rf, kf, krf)
+		hi := max(kingSquare.File, rf, kf, krf)
+
+		// every square the king and rook travel across must be vacant
+		blocked := false
+		for f := lo; f <= hi; f++ {
+			sq := v.board.GetSquare(f, rank)
+			if sq.Piece != nil && sq != kingSquare && sq != rookSquare {
+				blocked = true
+				break
 			}
 		}
-	}
 
-	// king side
-	if squares['h'] != nil &&
-		squares['h'].Piece != nil &&
-		squares['h'].Piece.Type == pieceRook &&
-		squares['h'].Piece.MoveCount == 0 {
-
-		if squares['f'].Piece == nil &&
-			squares['g'].Piece == nil {
-
-			res1, _ := v.board.Move(squares['e'], squares['f'], true, "")
-			canStepThroughF := !v.isSquareAttacked(squares['f'])
-			res1.Undo()
-			if canStepThroughF {
-				res2, _ := v.board.Move(squares['e'], squares['g'], true, "")
-				canLandOnG := !v.isSquareAttacked(squares['g'])
-				res2.Undo()
-				if canLandOnG {
-					moveSquares := getValidSquares(squares['e'])
-					if moveSquares != nil {
-						moveSquares = append(moveSquares, squares['g'])
-						for idx := range validMoves {
-							if validMoves[idx].origin == squares['e'] {
-								validMoves[idx].destinationSquares = moveSquares
-							}
-						}
-					}
-				}
+		if blocked {
+			continue
+		}
+
+		// the king may not pass through or land upon an attacked square
+		step := rune(1)
+		if kf < kingSquare.File {
+			step = -1
+		}
+
+		safe := true
+		for f := kingSquare.File; safe; f += step {
+			safe = isSafe(v.board.GetSquare(f, rank))
+			if f == kf {
+				break
+			}
+		}
+
+		if !safe {
+			continue
+		}
+
+		dest := v.board.GetSquare(kf, rank)
+		if v.game.c960 {
+			dest = rookSquare
+		}
+
+		added := false
+		for idx := range validMoves {
+			if validMoves[idx].origin == kingSquare {
+				validMoves[idx].destinationSquares = append(validMoves[idx].destinationSquares, dest)
+				added = true
 			}
 		}
+
+		if !added {
+			validMoves = append(validMoves, potentialMoves{
+				origin:             kingSquare,
+				destinationSquares: []*Square{dest},
+				piece:              king,
+			})
+		}
 	}
+
+	return validMoves
 }
 
 func (v *boardValidator) filterKingAttack(kingSquare *Square, moves []potentialMoves) []potentialMoves {
@@ -128,7 +142,7 @@ func (v *boardValidator) filterKingAttack(kingSquare *Square, moves []potentialM
 
 			isCheck := v.isSquareAttacked(kingSquare)
 			if res.Move.Piece.Type == pieceKing {
-				isCheck = v.isSquareAttacked(dest)
+				isCheck = v.isSquareAttacked(res.Move.PostSquare)
 			}
 
 			res.Undo()
@@ -244,12 +258,34 @@ func (v *boardValidator) isSquareAttacked(sq *Square) bool {
 	return len(v.findAttackers(sq)) > 0
 }
 
-func (v *boardValidator) Check() ([]potentialMoves, error) {
+// legalMoves determines every legal move for the validator's side without
+// emitting any events. Moves are generated from a bitboard representation of
+// the game's position.
+func (v *boardValidator) legalMoves() ([]potentialMoves, *Square, error) {
+	if v.board == nil {
+		return nil, nil, errors.New("board is invalid")
+	}
+
+	v.pos = newPosition(v.game)
+	mvs := v.pos.LegalMoves(make([]Move, 0, 64))
+
+	var kingSquare *Square
+	if k := v.pos.kingSquare(v.side); k >= 0 {
+		kingSquare = v.board.Squares[k]
+	}
+
+	return v.pos.potentialMoves(v.board, mvs), kingSquare, nil
+}
+
+// squareLegalMoves determines every legal move for the validator's side by
+// walking the squares of the board and simulating each move to test the
+// safety of the king. It is kept as a reference for the bitboard generator.
+func (v *boardValidator) squareLegalMoves() ([]potentialMoves, *Square, error) {
 	if v.board == nil {
-		return nil, errors.New("board is invalid")
+		return nil, nil, errors.New("board is invalid")
 	}
 
-	squares := v.board.getSquares(v.game.getCurrentSide())
+	squares := v.board.getSquares(v.side)
 	validMoves := []potentialMoves{}
 	var kingSquare *Square
 
@@ -265,7 +301,15 @@ func (v *boardValidator) Check() ([]potentialMoves, error) {
 		validator := CreatePieceValidator(sq.Piece.Type, v.board)
 		destSquares, err := validator.Check(sq)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+
+		// pawns only capture en passant onto the game's en passant target,
+		// which is not the case after a single step on a pawn's first move
+		if sq.Piece.Type == piecePawn {
+			destSquares = slices.DeleteFunc(destSquares, func(dest *Square) bool {
+				return dest.File != sq.File && dest.Piece == nil && dest != v.game.enP
+			})
 		}
 
 		if len(destSquares) > 0 {
@@ -276,13 +320,27 @@ func (v *boardValidator) Check() ([]potentialMoves, error) {
 		}
 	}
 
-	v.evaluateCastle(validMoves)
+	validMoves = v.evaluateCastle(validMoves)
 	validMoves = v.filterKingAttack(kingSquare, validMoves)
 
-	attackers := v.findAttackers(kingSquare)
-	for _, attacker := range attackers {
+	return validMoves, kingSquare, nil
+}
+
+// isCheck reports whether the validator's side is in check, once legalMoves
+// has been determined.
+func (v *boardValidator) isCheck() bool {
+	return v.pos != nil && v.pos.InCheck()
+}
+
+func (v *boardValidator) Check() ([]potentialMoves, error) {
+	validMoves, kingSquare, err := v.legalMoves()
+	if err != nil {
+		return nil, err
+	}
+
+	for attackers := v.pos.checkers(); attackers != 0; {
 		data := &KingThreatEvent{
-			AttackingSquare: attacker.square,
+			AttackingSquare: v.board.Squares[attackers.pop()],
 			KingSquare:      kingSquare,
 		}
 
@@ -335,14 +335,31 @@ func (b *Board) Move(src, dst *Square, sim bool, not ...string) (*moveResult, er
 		simulate:      sim,
 	}
 
-	dst.Piece = src.Piece
-	src.Piece = nil
-
-	mv.Castle = mv.Piece.Type == pieceKing &&
-		mv.prevMoveCount == 0 &&
-		mv.PrevSquare.File == 'e' &&
-		(dst.File == 'g' || dst.File == 'c')
-	mv.EnPassant = mv.Piece.Type == piecePawn && mv.CapturedPiece == nil && dst.File != mv.PrevSquare.File
+	// a king moving onto its own rook castles with that rook (Chess960 notation)
+	if wing, ok := castleWing(src, dst); ok && dst.Piece != nil {
+		kf, rf := castleDestinations(wing)
+		rook := dst.Piece
+
+		mv.Castle = true
+		mv.CapturedPiece = nil
+		mv.PostSquare = b.GetSquare(kf, dst.Rank)
+		mv.RookSource = dst
+		mv.RookDestination = b.GetSquare(rf, dst.Rank)
+
+		src.Piece = nil
+		dst.Piece = nil
+		mv.PostSquare.Piece = mv.Piece
+		mv.RookDestination.Piece = rook
+	} else {
+		dst.Piece = src.Piece
+		src.Piece = nil
+
+		mv.Castle = mv.Piece.Type == pieceKing &&
+			mv.prevMoveCount == 0 &&
+			mv.PrevSquare.File == 'e' &&
+			(dst.File == 'g' || dst.File == 'c')
+		mv.EnPassant = mv.Piece.Type == piecePawn && mv.CapturedPiece == nil && dst.File != mv.PrevSquare.File
+	}
 
 	if mv.EnPassant {
 		cs := b.GetSquare(dst.File, mv.PrevSquare.Rank)
@@ -353,7 +370,7 @@ func (b *Board) Move(src, dst *Square, sim bool, not ...string) (*moveResult, er
 		}
 	}
 
-	if mv.Castle {
+	if mv.Castle && mv.RookSource == nil {
 		rs := b.GetSquare('a', dst.Rank)
 		rd := b.GetSquare('d', dst.Rank)
 		if dst.File == 'g' {
@@ -395,19 +412,25 @@ func (b *Board) Move(src, dst *Square, sim bool, not ...string) (*moveResult, er
 			return
 		}
 
+		// lift the king and rook before restoring them, as their squares
+		// may overlap when castling in Chess960
+		if mv.Castle && mv.RookSource != nil && mv.RookDestination != nil {
+			rook := mv.RookDestination.Piece
+			mv.PostSquare.Piece = nil
+			mv.RookDestination.Piece = nil
+			mv.RookSource.Piece = rook
+		}
+
 		mv.PrevSquare.Piece = mv.Piece
-		mv.PostSquare.Piece = mv.CapturedPiece
+		if !mv.Castle {
+			mv.PostSquare.Piece = mv.CapturedPiece
+		}
 
 		if mv.EnPassant && mv.EnPassantCaptureSquare != nil {
 			mv.EnPassantCaptureSquare.Piece = mv.CapturedPiece
 			mv.PostSquare.Piece = nil
 		}
 
-		if mv.Castle && mv.RookSource != nil && mv.RookDestination != nil {
-			mv.RookSource.Piece = mv.RookDestination.Piece
-			mv.RookDestination.Piece = nil
-		}
-
 		if !mv.simulate {
 			mv.Piece.MoveCount = mv.prevMoveCount
 			b.LastMovedPiece = nil
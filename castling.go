@@ -0,0 +1,282 @@
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	castleKingSide  = 0 // castleKingSide indexes the right to castle towards the h-file.
+	castleQueenSide = 1 // castleQueenSide indexes the right to castle towards the a-file.
+)
+
+// castleRights tracks, for each side, the file of the rook that may still be
+// used to castle on the king side and on the queen side. A zero value means
+// the right is no longer available. Tracking rook files (rather than KQkq
+// flags) allows the rooks and king to start on any file, as in Chess960.
+type castleRights [2][2]rune
+
+// standardCastleRights returns the castling rights of a standard starting position.
+func standardCastleRights() castleRights {
+	return castleRights{
+		{'h', 'a'},
+		{'h', 'a'},
+	}
+}
+
+// backRank returns the rank on which the pieces of the given side start.
+func backRank(sd Side) int {
+	if sd == sideBlack {
+		return 8
+	}
+
+	return 1
+}
+
+// castleDestinations returns the files the king and rook occupy after castling
+// on the given wing.
+func castleDestinations(wing int) (rune, rune) {
+	if wing == castleQueenSide {
+		return 'c', 'd'
+	}
+
+	return 'g', 'f'
+}
+
+// findBackRankKing returns the square of the king when it stands on its side's back rank.
+func findBackRankKing(b *Board, sd Side) *Square {
+	for f := 'a'; f <= 'h'; f++ {
+		sq := b.GetSquare(f, backRank(sd))
+		if sq.Piece != nil && sq.Piece.Type == pieceKing && sq.Piece.Side == sd {
+			return sq
+		}
+	}
+
+	return nil
+}
+
+// findOutermostRook returns the file of the rook furthest from the king on the
+// given wing, or 0 when there is no rook on that wing.
+func findOutermostRook(b *Board, sd Side, kf rune, wing int) rune {
+	f, step := 'h', rune(-1)
+	if wing == castleQueenSide {
+		f, step = 'a', 1
+	}
+
+	for ; f != kf; f += step {
+		sq := b.GetSquare(f, backRank(sd))
+		if sq.Piece != nil && sq.Piece.Type == pieceRook && sq.Piece.Side == sd {
+			return f
+		}
+	}
+
+	return 0
+}
+
+// parseCastleRights reads the castling availability field of a FEN string.
+// Standard (KQkq), Shredder-FEN (HAha) and X-FEN (e.g. KQkb) fields are
+// supported. Rights that do not correspond to a king and rook on the back rank
+// are discarded.
+func parseCastleRights(fld string, b *Board) (castleRights, error) {
+	cr := castleRights{}
+	if fld == "-" || fld == "" {
+		return cr, nil
+	}
+
+	for _, ch := range fld {
+		sd := sideWhite
+		if ch >= 'a' && ch <= 'z' {
+			sd = sideBlack
+		}
+
+		kingSquare := findBackRankKing(b, sd)
+		if kingSquare == nil {
+			continue
+		}
+
+		switch u := rune(strings.ToUpper(string(ch))[0]); {
+		case u == 'K':
+			if rf := findOutermostRook(b, sd, kingSquare.File, castleKingSide); rf != 0 {
+				cr[sd][castleKingSide] = rf
+			}
+		case u == 'Q':
+			if rf := findOutermostRook(b, sd, kingSquare.File, castleQueenSide); rf != 0 {
+				cr[sd][castleQueenSide] = rf
+			}
+		case u >= 'A' && u <= 'H':
+			rf := u - 'A' + 'a'
+			sq := b.GetSquare(rf, backRank(sd))
+			if sq.Piece == nil || sq.Piece.Type != pieceRook || sq.Piece.Side != sd {
+				continue
+			}
+
+			if rf > kingSquare.File {
+				cr[sd][castleKingSide] = rf
+			}
+
+			if rf < kingSquare.File {
+				cr[sd][castleQueenSide] = rf
+			}
+		default:
+			return cr, fmt.Errorf("invalid FEN castling availability: %s", fld)
+		}
+	}
+
+	return cr, nil
+}
+
+// isStandard reports whether every remaining right castles a king on the
+// e-file with a rook from the a- or h-file.
+func (cr castleRights) isStandard(b *Board) bool {
+	for _, sd := range []Side{sideWhite, sideBlack} {
+		if cr[sd][castleKingSide] == 0 && cr[sd][castleQueenSide] == 0 {
+			continue
+		}
+
+		kingSquare := findBackRankKing(b, sd)
+		if kingSquare == nil || kingSquare.File != 'e' {
+			return false
+		}
+
+		if rf := cr[sd][castleKingSide]; rf != 0 && rf != 'h' {
+			return false
+		}
+
+		if rf := cr[sd][castleQueenSide]; rf != 0 && rf != 'a' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// fen returns the castling availability field of a FEN string. When shredder
+// is true the rook files are always used (Shredder-FEN), otherwise KQkq is
+// used unless another rook stands further out on the same wing (X-FEN).
+func (cr castleRights) fen(b *Board, shredder bool) string {
+	var fld strings.Builder
+
+	for _, sd := range []Side{sideWhite, sideBlack} {
+		kingSquare := findBackRankKing(b, sd)
+
+		for _, wing := range []int{castleKingSide, castleQueenSide} {
+			rf := cr[sd][wing]
+			if rf == 0 {
+				continue
+			}
+
+			ch := "KQ"[wing : wing+1]
+			if shredder || kingSquare == nil || findOutermostRook(b, sd, kingSquare.File, wing) != rf {
+				ch = strings.ToUpper(string(rf))
+			}
+
+			if sd == sideBlack {
+				ch = strings.ToLower(ch)
+			}
+
+			fld.WriteString(ch)
+		}
+	}
+
+	if fld.Len() == 0 {
+		return "-"
+	}
+
+	return fld.String()
+}
+
+// revoke removes the castling right that uses the rook on the given square.
+func (cr *castleRights) revoke(sq *Square) {
+	for _, sd := range []Side{sideWhite, sideBlack} {
+		if sq.Rank != backRank(sd) {
+			continue
+		}
+
+		for wing := range cr[sd] {
+			if cr[sd][wing] == sq.File {
+				cr[sd][wing] = 0
+			}
+		}
+	}
+}
+
+// castleWing reports whether a king move from src to dest is a castling move
+// and, if so, on which wing. Castling is either a king moving two files from
+// the e-file, or (as notated in Chess960) a king moving onto its own rook.
+func castleWing(src, dest *Square) (int, bool) {
+	if src.Piece == nil || src.Piece.Type != pieceKing || src.Rank != dest.Rank {
+		return 0, false
+	}
+
+	if dest.Piece != nil {
+		if dest.Piece.Type != pieceRook || dest.Piece.Side != src.Piece.Side {
+			return 0, false
+		}
+
+		if dest.File < src.File {
+			return castleQueenSide, true
+		}
+
+		return castleKingSide, true
+	}
+
+	if src.File == 'e' && dest.File == 'g' {
+		return castleKingSide, true
+	}
+
+	if src.File == 'e' && dest.File == 'c' {
+		return castleQueenSide, true
+	}
+
+	return 0, false
+}
+
+// chess960Rank returns the arrangement of the back rank pieces (e.g.
+// "RNBQKBNR") for the Chess960 starting position with the given identifier,
+// using the standard Scharnagl numbering (518 is the classical position).
+func chess960Rank(id int) (string, error) {
+	if id < 0 || id > 959 {
+		return "", fmt.Errorf("chess960 position must be between 0 and 959: %d", id)
+	}
+
+	rnk := make([]rune, 8)
+	n := id
+
+	// bishops on opposite colored squares
+	rnk[(n%4)*2+1] = 'B'
+	n /= 4
+	rnk[(n%4)*2] = 'B'
+	n /= 4
+
+	// fill the n-th empty square with the piece
+	place := func(p rune, idx int) {
+		for i := range rnk {
+			if rnk[i] != 0 {
+				continue
+			}
+
+			if idx == 0 {
+				rnk[i] = p
+				return
+			}
+			idx--
+		}
+	}
+
+	place('Q', n%6)
+	n /= 6
+
+	knights := [10][2]int{
+		{0, 1}, {0, 2}, {0, 3}, {0, 4}, {1, 2},
+		{1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4},
+	}
+	place('N', knights[n][1])
+	place('N', knights[n][0])
+
+	// the king always stands between the rooks
+	place('R', 0)
+	place('K', 0)
+	place('R', 0)
+
+	return string(rnk), nil
+}
@@ -0,0 +1,384 @@
+package chess
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	cecpMateScore      = 100000          // cecpMateScore is added to the number of moves to mate in thinking output.
+	defaultCECPMoves   = 40              // defaultCECPMoves is the number of moves of the default time control.
+	defaultCECPTimeout = 5 * time.Minute // defaultCECPTimeout is the base time of the default time control.
+)
+
+// CECPEngineOptions configures an engine speaking the XBoard (CECP) protocol.
+type CECPEngineOptions struct {
+	Name string // Name is the name reported to the GUI ("brozeph/chess" when empty).
+}
+
+// cecpEngine plays as an engine over the Chess Engine Communication Protocol
+// (CECP) used by XBoard and WinBoard, searching positions with BestMove.
+type cecpEngine struct {
+	base    time.Duration // base is the time of the time control.
+	cancel  context.CancelFunc
+	client  *AlgebraicGameClient
+	clk     time.Duration // clk is the time remaining on the engine's clock.
+	depth   int           // depth limits the search, when set with "sd".
+	discard atomic.Bool   // discard is set when the move of the search in progress must not be played.
+	done    chan struct{}
+	force   bool // force is true when the engine plays neither side.
+	inc     time.Duration
+	mps     int // mps is the number of moves per time control (zero for the whole game).
+	mu      sync.Mutex
+	opts    CECPEngineOptions
+	post    bool // post is true when thinking output is written while searching.
+	r       io.Reader
+	sd      Side          // sd is the side played by the engine.
+	st      time.Duration // st is the exact time to spend on each move, when set with "st".
+	w       io.Writer
+}
+
+// CreateCECPEngine creates an engine that reads XBoard commands from r and
+// writes its responses to w, such as the standard input and output of an
+// engine process loaded by XBoard or WinBoard. The state and legality of the
+// game are kept by an AlgebraicGameClient, as with the UCI engine.
+func CreateCECPEngine(r io.Reader, w io.Writer, opts ...CECPEngineOptions) *cecpEngine {
+	var o CECPEngineOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	if o.Name == "" {
+		o.Name = defaultEngineName
+	}
+
+	return &cecpEngine{
+		base:   defaultCECPTimeout,
+		client: CreateAlgebraicGameClient(AlgebraicClientOptions{AllowMovesAfterGameOver: true}),
+		clk:    defaultCECPTimeout,
+		mps:    defaultCECPMoves,
+		opts:   o,
+		r:      r,
+		sd:     sideBlack,
+		w:      w,
+	}
+}
+
+// Run handles commands until "quit" is received or the input ends. Searches
+// run in the background, so that "?" and "ping" are answered while thinking,
+// and are derived from ctx. Cancelling ctx stops any search and ends Run
+// once the next command is read.
+func (e *cecpEngine) Run(ctx context.Context) error {
+	defer e.stop(true)
+
+	scn := bufio.NewScanner(e.r)
+	for scn.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if quit := e.handle(ctx, strings.Fields(scn.Text())); quit {
+			return nil
+		}
+	}
+
+	return scn.Err()
+}
+
+// handle responds to a single command, returning true once the engine should quit.
+func (e *cecpEngine) handle(ctx context.Context, args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	switch args[0] {
+	case "protover":
+		e.send(
+			"feature myname=%q ping=1 setboard=1 usermove=1 time=1 draw=0 sigint=0 sigterm=0 reuse=1 analyze=0 colors=0 variants=\"normal\" done=1",
+			e.opts.Name)
+	case "new":
+		e.stop(true)
+		e.client = CreateAlgebraicGameClient(AlgebraicClientOptions{AllowMovesAfterGameOver: true})
+		e.force, e.sd, e.depth, e.clk = false, sideBlack, 0, e.base
+	case "force":
+		e.stop(true)
+		e.force = true
+	case "go":
+		e.stop(true)
+		e.force, e.sd = false, e.client.game.getCurrentSide()
+		e.think(ctx)
+	case "usermove":
+		e.stop(true)
+		e.userMove(ctx, args[1:])
+	case "setboard":
+		e.stop(true)
+		e.setBoard(args[1:])
+	case "undo":
+		e.stop(true)
+		e.undo(1, args[0])
+	case "remove":
+		e.stop(true)
+		e.undo(2, args[0])
+	case "result":
+		e.stop(true)
+		e.force = true
+	case "?":
+		e.stop(false)
+	case "level", "st", "sd", "time":
+		e.timeControl(args)
+	case "ping":
+		e.send("pong %s", strings.Join(args[1:], " "))
+	case "post":
+		e.post = true
+	case "nopost":
+		e.post = false
+	case "quit":
+		return true
+	case "xboard", "accepted", "rejected", "random", "hard", "easy", "computer", "name", "rating", "otim", "draw":
+		// not supported, but not errors either
+	default:
+		e.send("Error (unknown command): %s", args[0])
+	}
+
+	return false
+}
+
+// send writes a line to the GUI.
+func (e *cecpEngine) send(format string, a ...any) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	fmt.Fprintf(e.w, format+"\n", a...)
+}
+
+// userMove handles "usermove <move>", replying once it is the engine's turn.
+// Moves are in coordinate notation, as with UCI (e.g. "e2e4", "e7e8q").
+func (e *cecpEngine) userMove(ctx context.Context, args []string) {
+	if len(args) == 0 {
+		e.send("Error (no move): usermove")
+		return
+	}
+
+	if _, err := e.client.MoveUCI(args[0]); err != nil {
+		e.send("Illegal move: %s", args[0])
+		return
+	}
+
+	if !e.force && e.client.game.getCurrentSide() == e.sd {
+		e.think(ctx)
+	}
+}
+
+// setBoard handles "setboard <fen>".
+func (e *cecpEngine) setBoard(args []string) {
+	client, err := CreateAlgebraicGameClientFromFEN(
+		strings.Join(args, " "),
+		AlgebraicClientOptions{AllowMovesAfterGameOver: true})
+	if err != nil {
+		e.send("tellusererror Illegal position")
+		return
+	}
+
+	e.client = client
+}
+
+// undo handles "undo" and "remove", taking back n moves.
+func (e *cecpEngine) undo(n int, cmd string) {
+	for range n {
+		if err := e.client.Undo(); err != nil {
+			e.send("Error (no moves to undo): %s", cmd)
+			return
+		}
+	}
+}
+
+// timeControl handles "level <mps> <base> <inc>", "st <seconds>",
+// "sd <depth>" and "time <centiseconds>".
+func (e *cecpEngine) timeControl(args []string) {
+	switch {
+	case args[0] == "level" && len(args) == 4:
+		mps, err := strconv.Atoi(args[1])
+		if err != nil {
+			break
+		}
+
+		base, err := parseCECPBase(args[2])
+		if err != nil {
+			break
+		}
+
+		inc, err := strconv.ParseFloat(args[3], 64)
+		if err != nil {
+			break
+		}
+
+		e.mps, e.base, e.clk, e.inc = mps, base, base, time.Duration(inc*float64(time.Second))
+		e.st = 0
+		return
+	case len(args) == 2:
+		n, err := strconv.ParseFloat(args[1], 64)
+		if err != nil {
+			break
+		}
+
+		switch args[0] {
+		case "st":
+			e.st = time.Duration(n * float64(time.Second))
+		case "sd":
+			e.depth = int(n)
+		case "time":
+			e.clk = time.Duration(n) * 10 * time.Millisecond
+		}
+		return
+	}
+
+	e.send("Error (invalid arguments): %s", strings.Join(args, " "))
+}
+
+// parseCECPBase parses the base time of "level", given in minutes or as
+// minutes and seconds (e.g. "5" or "0:30").
+func parseCECPBase(s string) (time.Duration, error) {
+	mins, secs, _ := strings.Cut(s, ":")
+
+	m, err := strconv.Atoi(mins)
+	if err != nil {
+		return 0, err
+	}
+
+	d := time.Duration(m) * time.Minute
+	if secs != "" {
+		n, err := strconv.Atoi(secs)
+		if err != nil {
+			return 0, err
+		}
+		d += time.Duration(n) * time.Second
+	}
+
+	return d, nil
+}
+
+// limits returns the limits of the engine's next search.
+func (e *cecpEngine) limits() SearchLimits {
+	limits := SearchLimits{Depth: e.depth, Time: e.st}
+	if limits.Time > 0 || e.clk <= 0 {
+		return limits
+	}
+
+	mtg := 0
+	if e.mps > 0 {
+		mtg = e.mps - (newPosition(e.client.game).fmn-1)%e.mps
+	}
+	limits.Time = moveTime(e.clk, e.inc, mtg)
+
+	return limits
+}
+
+// think searches in the background for the move of the engine, playing and
+// reporting it once a limit is reached or "?" is received. A game that has
+// ended on the board, before or after the engine's move, is claimed so that
+// GUIs which do not adjudicate are not left waiting.
+func (e *cecpEngine) think(ctx context.Context) {
+	if e.claim() {
+		return
+	}
+
+	limits := e.limits()
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	e.cancel, e.done = cancel, done
+	e.discard.Store(false)
+
+	client := e.client
+	var report func(*SearchResult)
+	if e.post {
+		// principal variations are notated on a copy of the position
+		pos := client.Position()
+		report = func(res *SearchResult) {
+			e.thinking(res, pos)
+		}
+	}
+
+	go func() {
+		defer close(done)
+
+		res, err := client.bestMove(ctx, limits, report)
+		if err != nil || e.discard.Load() {
+			return
+		}
+
+		if _, err := client.MoveUCI(res.UCI); err != nil {
+			e.send("Error (%v): %s", err, res.UCI)
+			return
+		}
+
+		e.send("move %s", res.UCI)
+		e.claim()
+	}()
+}
+
+// claim sends the result of a game that has ended on the board, with the
+// reason as a comment (e.g. "1-0 {White mates}"), returning false when the
+// game has not ended.
+func (e *cecpEngine) claim() bool {
+	status, err := e.client.Status()
+	if err != nil || status.Result == ResultOngoing {
+		return false
+	}
+
+	reason := status.Termination.String()
+	switch {
+	case status.Termination != TerminationCheckmate:
+		reason = strings.ToUpper(reason[:1]) + reason[1:]
+	case status.Result == ResultWhiteWins:
+		reason = "White mates"
+	default:
+		reason = "Black mates"
+	}
+
+	e.send("%s {%s}", status.Result, reason)
+	return true
+}
+
+// thinking writes the thinking output of a completed iteration of the search:
+// the depth, the score in centipawns, the time in centiseconds, the number
+// of nodes and the principal variation. Mates are scored as 100000 plus the
+// number of moves to mate.
+func (e *cecpEngine) thinking(res *SearchResult, pos *Position) {
+	score := res.Score
+	switch {
+	case res.Mate > 0:
+		score = cecpMateScore + res.Mate
+	case res.Mate < 0:
+		score = -cecpMateScore + res.Mate
+	}
+
+	pv := res.PV
+	if pos != nil {
+		pv = uciToSANLine(pos, res.PV)
+	}
+
+	e.send(
+		"%d %d %d %d %s",
+		res.Depth, score, res.Time.Milliseconds()/10, res.Nodes, strings.Join(pv, " "))
+}
+
+// stop stops the search in progress, waiting for it to end. The move found
+// is played and reported unless discard is true.
+func (e *cecpEngine) stop(discard bool) {
+	if e.cancel == nil {
+		return
+	}
+
+	e.discard.Store(discard)
+	e.cancel()
+	<-e.done
+	e.cancel, e.done = nil, nil
+}
@@ -0,0 +1,193 @@
+package chess
+
+import (
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startCECPEngine runs an XBoard engine until the test ends.
+func startCECPEngine(t *testing.T) *engineSession {
+	t.Helper()
+
+	return startEngine(t, func(r io.Reader, w io.Writer) error {
+		return CreateCECPEngine(r, w, CECPEngineOptions{Name: "test engine"}).Run(context.Background())
+	})
+}
+
+// engineMove returns the move of a "move" line.
+func engineMove(ln string) string {
+	return strings.TrimPrefix(ln, "move ")
+}
+
+func TestCECPEngineHandshake(t *testing.T) {
+	s := startCECPEngine(t)
+
+	s.send("xboard", "protover 2")
+	ln := s.expect(t, "feature")[0]
+	for _, f := range []string{`myname="test engine"`, "usermove=1", "setboard=1", "ping=1", "done=1"} {
+		if !strings.Contains(ln, f) {
+			t.Fatalf("expected feature %s, got %q", f, ln)
+		}
+	}
+
+	s.send("accepted usermove", "ping 7")
+	s.expect(t, "pong 7")
+}
+
+func TestCECPEnginePlay(t *testing.T) {
+	s := startCECPEngine(t)
+
+	s.send("new", "sd 3", "usermove e2e4")
+	lns := s.expect(t, "move")
+
+	client := CreateAlgebraicGameClient()
+	mustMove(t, client, "e4")
+
+	mv := engineMove(lns[len(lns)-1])
+	if !slices.Contains(client.UCIMoves(), mv) {
+		t.Fatalf("expected a legal move for black, got %s", mv)
+	}
+
+	// the engine keeps playing black
+	s.send("usermove d2d4")
+	s.expect(t, "move")
+}
+
+func TestCECPEngineMate(t *testing.T) {
+	s := startCECPEngine(t)
+
+	s.send("new", "force", "setboard 6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "post", "sd 4", "go")
+	lns := s.expect(t, "move")
+
+	if mv := engineMove(lns[len(lns)-1]); mv != "a1a8" {
+		t.Fatalf("expected a1a8, got %s", mv)
+	}
+
+	// thinking output scores mates as 100000 plus the moves to mate
+	if flds := strings.Fields(lns[len(lns)-2]); len(flds) != 5 || flds[1] != "100001" || flds[4] != "Ra8#" {
+		t.Fatalf("expected thinking output for a mate, got %q", lns[len(lns)-2])
+	}
+
+	// the engine claims the game it has won
+	if lns := s.expect(t, "1-0"); lns[len(lns)-1] != "1-0 {White mates}" {
+		t.Fatalf("expected a claim of the result, got %q", lns[len(lns)-1])
+	}
+}
+
+func TestCECPEngineClaimsResult(t *testing.T) {
+	s := startCECPEngine(t)
+
+	// mated by the move of its opponent
+	s.send("new", "setboard 6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "usermove a1a8")
+	if lns := s.expect(t, "1-0"); lns[len(lns)-1] != "1-0 {White mates}" {
+		t.Fatalf("expected a claim of checkmate, got %q", lns[len(lns)-1])
+	}
+
+	// asked to move in stalemate
+	s.send("force", "setboard k7/2Q5/1K6/8/8/8/8/8 b - - 0 1", "go")
+	if lns := s.expect(t, "1/2-1/2"); lns[len(lns)-1] != "1/2-1/2 {Stalemate}" {
+		t.Fatalf("expected a claim of stalemate, got %q", lns[len(lns)-1])
+	}
+}
+
+func TestCECPEngineForceAndUndo(t *testing.T) {
+	s := startCECPEngine(t)
+
+	// the engine plays neither side in force mode
+	s.send("new", "force", "usermove e2e4", "usermove e7e5", "ping 1")
+	if lns := s.expect(t, "pong 1"); len(lns) != 1 {
+		t.Fatalf("expected no moves in force mode, got %v", lns)
+	}
+
+	// taking back black's move leaves the engine to play black
+	s.send("undo", "sd 2", "go")
+	lns := s.expect(t, "move")
+
+	client := CreateAlgebraicGameClient()
+	mustMove(t, client, "e4")
+
+	if mv := engineMove(lns[len(lns)-1]); !slices.Contains(client.UCIMoves(), mv) {
+		t.Fatalf("expected a legal move for black, got %s", mv)
+	}
+
+	s.send("remove", "remove", "undo", "ping 2")
+	lns = s.expect(t, "pong 2")
+	if !strings.HasPrefix(lns[0], "Error") {
+		t.Fatalf("expected an error undoing beyond the start, got %v", lns)
+	}
+}
+
+func TestCECPEngineMoveNow(t *testing.T) {
+	s := startCECPEngine(t)
+
+	s.send("new", "level 0 60 0", "post", "force", "go")
+	s.expect(t, "2 ")
+
+	s.send("?")
+	lns := s.expect(t, "move")
+
+	if mv := engineMove(lns[len(lns)-1]); !slices.Contains(CreateAlgebraicGameClient().UCIMoves(), mv) {
+		t.Fatalf("expected a legal move for white, got %s", mv)
+	}
+}
+
+func TestCECPEngineResult(t *testing.T) {
+	s := startCECPEngine(t)
+
+	s.send("new", "level 0 60 0", "post", "go")
+	s.expect(t, "2 ")
+
+	// the search is abandoned once the game has ended
+	s.send("result 1-0 {White resigns}", "ping 1")
+	for _, ln := range s.expect(t, "pong 1") {
+		if strings.HasPrefix(ln, "move") {
+			t.Fatalf("expected no move once the game has ended, got %q", ln)
+		}
+	}
+}
+
+func TestCECPEngineInvalidCommands(t *testing.T) {
+	s := startCECPEngine(t)
+
+	s.send("new", "usermove e2e5")
+	s.expect(t, "Illegal move: e2e5")
+
+	s.send("setboard 8/8/8 w - - 0 1")
+	s.expect(t, "tellusererror Illegal position")
+
+	s.send("level 40 five 0")
+	s.expect(t, "Error (invalid arguments)")
+
+	s.send("bogus")
+	s.expect(t, "Error (unknown command): bogus")
+}
+
+func TestCECPEngineTimeControl(t *testing.T) {
+	e := CreateCECPEngine(nil, io.Discard)
+	ctx := context.Background()
+
+	tests := []struct {
+		cmds []string
+		want SearchLimits
+	}{
+		// 60 seconds for the 40 moves of the time control
+		{[]string{"level 40 5 0", "time 6000"}, SearchLimits{Time: 1500 * time.Millisecond}},
+		// 2 minutes 30 seconds with a 5 second increment
+		{[]string{"level 0 2:30 5"}, SearchLimits{Time: 8750 * time.Millisecond}},
+		{[]string{"st 2", "sd 6"}, SearchLimits{Depth: 6, Time: 2 * time.Second}},
+	}
+
+	for _, tc := range tests {
+		for _, cmd := range tc.cmds {
+			e.handle(ctx, strings.Fields(cmd))
+		}
+
+		if got := e.limits(); got != tc.want {
+			t.Fatalf("expected %+v after %v, got %+v", tc.want, tc.cmds, got)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package chess
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestChess960Rank(t *testing.T) {
+	for id, exp := range map[int]string{
+		0:   "BBQNNRKR",
+		518: "RNBQKBNR",
+		959: "RKRNNQBB",
+	} {
+		rnk, err := chess960Rank(id)
+		if err != nil {
+			t.Fatalf("chess960Rank(%d) error = %v", id, err)
+		}
+		if rnk != exp {
+			t.Fatalf("chess960Rank(%d) = %s, want %s", id, rnk, exp)
+		}
+	}
+
+	if _, err := chess960Rank(960); err == nil {
+		t.Fatalf("expected error for position 960")
+	}
+}
+
+func TestCreateChess960GameClient(t *testing.T) {
+	client, err := CreateChess960GameClient(0)
+	if err != nil {
+		t.Fatalf("CreateChess960GameClient() error = %v", err)
+	}
+
+	exp := "bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w KQkq - 0 1"
+	if got := client.FEN(); got != exp {
+		t.Fatalf("FEN mismatch:\n got: %s\nwant: %s", got, exp)
+	}
+
+	shredder, err := CreateChess960GameClient(0, AlgebraicClientOptions{ShredderFEN: true})
+	if err != nil {
+		t.Fatalf("CreateChess960GameClient() error = %v", err)
+	}
+
+	exp = "bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w HFhf - 0 1"
+	if got := shredder.FEN(); got != exp {
+		t.Fatalf("FEN mismatch:\n got: %s\nwant: %s", got, exp)
+	}
+}
+
+func TestChess960Castling(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN("rk5r/8/8/8/8/8/8/RK5R w HAha - 0 1")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	if got := client.FEN(); got != "rk5r/8/8/8/8/8/8/RK5R w KQkq - 0 1" {
+		t.Fatalf("expected KQkq castling availability, got %s", got)
+	}
+
+	mvs := client.UCIMoves()
+	for _, mv := range []string{"b1a1", "b1h1"} {
+		if !slices.Contains(mvs, mv) {
+			t.Fatalf("expected castling move %s in %v", mv, mvs)
+		}
+	}
+
+	res, err := client.MoveUCI("b1h1")
+	if err != nil {
+		t.Fatalf("MoveUCI() error = %v", err)
+	}
+	if !res.Move.Castle || res.Move.Algebraic != "O-O" {
+		t.Fatalf("expected O-O castle, got %s", res.Move.Algebraic)
+	}
+
+	exp := "rk5r/8/8/8/8/8/8/R4RK1 b kq - 1 1"
+	if got := client.FEN(); got != exp {
+		t.Fatalf("FEN mismatch:\n got: %s\nwant: %s", got, exp)
+	}
+
+	res.Undo()
+	if got := client.game.Board.fenPiecePlacement(); got != "rk5r/8/8/8/8/8/8/RK5R" {
+		t.Fatalf("expected board restored after undo, got %s", got)
+	}
+
+	mustMove(t, client, "O-O-O")
+	exp = "rk5r/8/8/8/8/8/8/2KR3R b kq - 1 1"
+	if got := client.FEN(); got != exp {
+		t.Fatalf("FEN mismatch:\n got: %s\nwant: %s", got, exp)
+	}
+}
+
+func TestChess960CastlingOverlappingSquares(t *testing.T) {
+	// the king already stands on its destination and the rook crosses the king's path
+	client, err := CreateAlgebraicGameClientFromFEN("4k3/8/8/8/8/8/8/5RKR w H - 0 1")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	if _, ok := mustStatus(t, client, false).NotatedMoves["0-0"]; ok {
+		t.Fatalf("expected castling to be blocked by the rook on f1")
+	}
+
+	client, err = CreateAlgebraicGameClientFromFEN("4k3/8/8/8/8/8/8/6KR w H - 0 1")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	res := mustMove(t, client, "O-O")
+	if got := client.game.Board.fenPiecePlacement(); got != "4k3/8/8/8/8/8/8/5RK1" {
+		t.Fatalf("unexpected placement after castling: %s", got)
+	}
+
+	res.Undo()
+	if got := client.game.Board.fenPiecePlacement(); got != "4k3/8/8/8/8/8/8/6KR" {
+		t.Fatalf("unexpected placement after undo: %s", got)
+	}
+}
+
+func TestChess960CastlingThroughCheck(t *testing.T) {
+	// the black rook on d8 attacks d1, which the king crosses when castling king side
+	client, err := CreateAlgebraicGameClientFromFEN("1k1r4/8/8/8/8/8/8/1K5R w H - 0 1")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	if slices.Contains(client.UCIMoves(), "b1h1") {
+		t.Fatalf("expected castling through check to be illegal")
+	}
+}
+
+func TestXFENCastlingAvailability(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN("4k3/8/8/8/8/8/8/R3K1RR w GQ - 0 1")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	if got := client.FEN(); got != "4k3/8/8/8/8/8/8/R3K1RR w GQ - 0 1" {
+		t.Fatalf("expected X-FEN castling availability, got %s", got)
+	}
+
+	mustMove(t, client, "e1g1")
+	if got := client.FEN(); got != "4k3/8/8/8/8/8/8/R4RKR b - - 1 1" {
+		t.Fatalf("unexpected FEN after castling: %s", got)
+	}
+}
+
+func TestKingTakesRookCastlingInput(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+
+	for _, mv := range []string{"e4", "e5", "Nf3", "Nc6", "Bc4", "Bc5"} {
+		mustMove(t, client, mv)
+	}
+
+	res, err := client.MoveUCI("e1h1")
+	if err != nil {
+		t.Fatalf("MoveUCI() error = %v", err)
+	}
+	if !res.Move.Castle || res.Move.PostSquare.name() != "g1" {
+		t.Fatalf("expected castle to g1")
+	}
+}
+
+func TestCastleRightsRevokedByCapture(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN("r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	mustMove(t, client, "Rxa8+")
+
+	exp := "R3k2r/8/8/8/8/8/8/4K2R b Kk - 0 1"
+	if got := client.FEN(); got != exp {
+		t.Fatalf("FEN mismatch:\n got: %s\nwant: %s", got, exp)
+	}
+}
+
+func TestFENCastlingAvailabilityIsRespected(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN("r3k2r/8/8/8/8/8/8/R3K2R w Qk - 0 1")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	status := mustStatus(t, client, false)
+	if _, ok := status.NotatedMoves["0-0"]; ok {
+		t.Fatalf("expected king side castling to be unavailable")
+	}
+	if _, ok := status.NotatedMoves["0-0-0"]; !ok {
+		t.Fatalf("expected queen side castling to be available")
+	}
+}
+
+func TestParsePGNChess960(t *testing.T) {
+	pgn := `[Variant "Chess960"]
+[SetUp "1"]
+[FEN "rk5r/8/8/8/8/8/8/RK5R w KQkq - 0 1"]
+
+1. O-O O-O-O *`
+
+	pg, err := ParsePGN(pgn)
+	if err != nil {
+		t.Fatalf("ParsePGN() error = %v", err)
+	}
+
+	if got := pg.Client.FEN(); got != "2kr3r/8/8/8/8/8/8/R4RK1 w - - 2 2" {
+		t.Fatalf("unexpected FEN %s", got)
+	}
+}
+
+func TestInvalidCastlingAvailability(t *testing.T) {
+	if _, err := CreateAlgebraicGameClientFromFEN("r3k2r/8/8/8/8/8/8/R3K2R w KX - 0 1"); err == nil {
+		t.Fatalf("expected error for invalid castling availability")
+	}
+}
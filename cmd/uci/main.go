@@ -0,0 +1,20 @@
+// Command uci runs the built-in engine of github.com/brozeph/chess over the
+// Universal Chess Interface (UCI) protocol, reading commands from standard
+// input and writing responses to standard output, so that it can be loaded
+// by chess GUIs and test harnesses.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/brozeph/chess"
+)
+
+func main() {
+	eng := chess.CreateUCIEngine(os.Stdin, os.Stdout)
+	if err := eng.Run(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+}
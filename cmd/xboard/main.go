@@ -0,0 +1,20 @@
+// Command xboard runs the built-in engine of github.com/brozeph/chess over the
+// XBoard (CECP) protocol, reading commands from standard input and writing
+// responses to standard output, so that it can be loaded by XBoard, WinBoard
+// and tournament managers that speak CECP.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/brozeph/chess"
+)
+
+func main() {
+	eng := chess.CreateCECPEngine(os.Stdin, os.Stdout)
+	if err := eng.Run(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+}
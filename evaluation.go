@@ -0,0 +1,387 @@
+package chess
+
+// materialValues are the values of the pieces in centipawns, by piece type.
+var materialValues = [6]int{
+	pieceBishop: 330,
+	pieceKing:   0,
+	pieceKnight: 320,
+	piecePawn:   100,
+	pieceQueen:  900,
+	pieceRook:   500,
+}
+
+// phaseWeights are the contributions of the pieces to the game phase, which
+// is highest (totalPhase) with every piece on the board and zero once only
+// kings and pawns remain.
+var phaseWeights = [6]int{
+	pieceBishop: 1,
+	pieceKnight: 1,
+	pieceQueen:  4,
+	pieceRook:   2,
+}
+
+// totalPhase is the game phase of the initial position.
+const totalPhase = 24
+
+// pieceSquareTables are bonuses in centipawns for a piece standing on each
+// square, laid out as seen by white with a8 first. The king uses its middle
+// game table, blended into kingEndgameTable as material comes off.
+var pieceSquareTables = [6][64]int{
+	pieceBishop: {
+		-20, -10, -10, -10, -10, -10, -10, -20,
+		-10, 0, 0, 0, 0, 0, 0, -10,
+		-10, 0, 5, 10, 10, 5, 0, -10,
+		-10, 5, 5, 10, 10, 5, 5, -10,
+		-10, 0, 10, 10, 10, 10, 0, -10,
+		-10, 10, 10, 10, 10, 10, 10, -10,
+		-10, 5, 0, 0, 0, 0, 5, -10,
+		-20, -10, -10, -10, -10, -10, -10, -20,
+	},
+	pieceKing: {
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-20, -30, -30, -40, -40, -30, -30, -20,
+		-10, -20, -20, -20, -20, -20, -20, -10,
+		20, 20, 0, 0, 0, 0, 20, 20,
+		20, 30, 10, 0, 0, 10, 30, 20,
+	},
+	pieceKnight: {
+		-50, -40, -30, -30, -30, -30, -40, -50,
+		-40, -20, 0, 0, 0, 0, -20, -40,
+		-30, 0, 10, 15, 15, 10, 0, -30,
+		-30, 5, 15, 20, 20, 15, 5, -30,
+		-30, 0, 15, 20, 20, 15, 0, -30,
+		-30, 5, 10, 15, 15, 10, 5, -30,
+		-40, -20, 0, 5, 5, 0, -20, -40,
+		-50, -40, -30, -30, -30, -30, -40, -50,
+	},
+	piecePawn: {
+		0, 0, 0, 0, 0, 0, 0, 0,
+		50, 50, 50, 50, 50, 50, 50, 50,
+		10, 10, 20, 30, 30, 20, 10, 10,
+		5, 5, 10, 25, 25, 10, 5, 5,
+		0, 0, 0, 20, 20, 0, 0, 0,
+		5, -5, -10, 0, 0, -10, -5, 5,
+		5, 10, 10, -20, -20, 10, 10, 5,
+		0, 0, 0, 0, 0, 0, 0, 0,
+	},
+	pieceQueen: {
+		-20, -10, -10, -5, -5, -10, -10, -20,
+		-10, 0, 0, 0, 0, 0, 0, -10,
+		-10, 0, 5, 5, 5, 5, 0, -10,
+		-5, 0, 5, 5, 5, 5, 0, -5,
+		0, 0, 5, 5, 5, 5, 0, -5,
+		-10, 5, 5, 5, 5, 5, 0, -10,
+		-10, 0, 5, 0, 0, 0, 0, -10,
+		-20, -10, -10, -5, -5, -10, -10, -20,
+	},
+	pieceRook: {
+		0, 0, 0, 0, 0, 0, 0, 0,
+		5, 10, 10, 10, 10, 10, 10, 5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		0, 0, 0, 5, 5, 0, 0, 0,
+	},
+}
+
+// kingEndgameTable draws the king towards the centre once material is reduced.
+var kingEndgameTable = [64]int{
+	-50, -40, -30, -20, -20, -30, -40, -50,
+	-30, -20, -10, 0, 0, -10, -20, -30,
+	-30, -10, 20, 30, 30, 20, -10, -30,
+	-30, -10, 30, 40, 40, 30, -10, -30,
+	-30, -10, 30, 40, 40, 30, -10, -30,
+	-30, -10, 20, 30, 30, 20, -10, -30,
+	-30, -30, 0, 0, 0, 0, -30, -30,
+	-50, -30, -30, -30, -30, -30, -30, -50,
+}
+
+// mobilityWeights are the bonuses in centipawns for each square a piece can
+// move to beyond the number given by mobilityBaselines.
+var mobilityWeights = [6]int{
+	pieceBishop: 5,
+	pieceKnight: 4,
+	pieceQueen:  1,
+	pieceRook:   2,
+}
+
+// mobilityBaselines are the typical number of squares each piece can move to.
+var mobilityBaselines = [6]int{
+	pieceBishop: 7,
+	pieceKnight: 4,
+	pieceQueen:  14,
+	pieceRook:   7,
+}
+
+// passedPawnBonuses are the bonuses in centipawns for a passed pawn by the
+// number of ranks it has advanced.
+var passedPawnBonuses = [8]int{0, 5, 10, 20, 35, 60, 100, 0}
+
+// kingAttackWeights are the penalties in centipawns for each square next to
+// the king attacked by a piece of the given type.
+var kingAttackWeights = [6]int{
+	pieceBishop: 10,
+	pieceKnight: 10,
+	pieceQueen:  25,
+	pieceRook:   15,
+}
+
+const (
+	doubledPawnPenalty  = 15 // doubledPawnPenalty is deducted for each pawn on a file beyond the first.
+	isolatedPawnPenalty = 15 // isolatedPawnPenalty is deducted for each pawn with no pawns of its side on adjacent files.
+	shelterBonus        = 10 // shelterBonus is awarded for a pawn on the rank in front of the king, on its file or an adjacent one.
+	shelterFarBonus     = 5  // shelterFarBonus is awarded for a sheltering pawn a further rank away.
+	shelterOpenPenalty  = 15 // shelterOpenPenalty is deducted for a file next to the king without a sheltering pawn.
+)
+
+// Evaluation is a static evaluation of a position in centipawns from white's
+// point of view (positive when white is better), along with the terms it is
+// made up of.
+type Evaluation struct {
+	KingSafety    int // KingSafety rewards pawns sheltering the king and penalises attacks on the squares around it, fading as material comes off.
+	Material      int // Material is the balance of the values of the pieces (a pawn being 100).
+	Mobility      int // Mobility rewards knights, bishops, rooks and queens for the squares they can move to.
+	PawnStructure int // PawnStructure rewards passed pawns and penalises doubled and isolated pawns.
+	PieceSquares  int // PieceSquares rewards pieces for the squares they stand on.
+	Total         int // Total is the sum of the terms.
+}
+
+// Evaluate returns the static evaluation of the current position, without
+// searching any moves.
+func (c *AlgebraicGameClient) Evaluate() Evaluation {
+	return newPosition(c.game).Evaluate()
+}
+
+// Evaluate returns the static evaluation of the position, without searching
+// any moves.
+func (p *Position) Evaluate() Evaluation {
+	ph := p.phase()
+
+	var ev Evaluation
+	for _, sd := range []Side{sideWhite, sideBlack} {
+		sgn := 1
+		if sd == sideBlack {
+			sgn = -1
+		}
+
+		mat, psq := p.pieceValues(sd, ph)
+		ev.Material += sgn * mat
+		ev.PieceSquares += sgn * psq
+		ev.Mobility += sgn * p.mobility(sd)
+		ev.PawnStructure += sgn * p.pawnStructure(sd)
+		ev.KingSafety += sgn * p.kingSafety(sd, ph)
+	}
+	ev.Total = ev.KingSafety + ev.Material + ev.Mobility + ev.PawnStructure + ev.PieceSquares
+
+	return ev
+}
+
+// tableIndex returns the index within a piece-square table of a square, as
+// seen by the given side.
+func tableIndex(sq int, sd Side) int {
+	if sd == sideWhite {
+		return sq ^ 56
+	}
+
+	return sq
+}
+
+// phase returns the game phase of the position, between zero (kings and
+// pawns only) and totalPhase (every piece on the board).
+func (p *Position) phase() int {
+	ph := 0
+	for sd := range p.pieces {
+		for pt, bb := range p.pieces[sd] {
+			ph += phaseWeights[pt] * bb.count()
+		}
+	}
+
+	return min(ph, totalPhase)
+}
+
+// evaluate returns the static evaluation of the position in centipawns from
+// the point of view of the side to move.
+func (p *Position) evaluate() int {
+	if p.side == sideBlack {
+		return -p.Evaluate().Total
+	}
+
+	return p.Evaluate().Total
+}
+
+// pieceValues returns the material of the given side and the bonuses of its
+// pieces for the squares they stand on.
+func (p *Position) pieceValues(sd Side, ph int) (int, int) {
+	mat, psq := 0, 0
+	for pt, bb := range p.pieces[sd] {
+		mat += materialValues[pt] * bb.count()
+
+		for bb != 0 {
+			idx := tableIndex(bb.pop(), sd)
+			if pieceType(pt) == pieceKing {
+				psq += (pieceSquareTables[pt][idx]*ph + kingEndgameTable[idx]*(totalPhase-ph)) / totalPhase
+				continue
+			}
+			psq += pieceSquareTables[pt][idx]
+		}
+	}
+
+	return mat, psq
+}
+
+// pieceAttacks returns the squares attacked by a piece of the given type on sq.
+func pieceAttacks(pt pieceType, sq int, occ bitboard) bitboard {
+	switch pt {
+	case pieceBishop:
+		return bishopAttacks(sq, occ)
+	case pieceKing:
+		return kingAttacks[sq]
+	case pieceKnight:
+		return knightAttacks[sq]
+	case pieceQueen:
+		return bishopAttacks(sq, occ) | rookAttacks(sq, occ)
+	case pieceRook:
+		return rookAttacks(sq, occ)
+	default:
+		return 0
+	}
+}
+
+// pawnAttacksBy returns the squares attacked by the pawns of the given side.
+func (p *Position) pawnAttacksBy(sd Side) bitboard {
+	var att bitboard
+	for pawns := p.pieces[sd][piecePawn]; pawns != 0; {
+		att |= pawnAttacks[sd][pawns.pop()]
+	}
+
+	return att
+}
+
+// mobility returns the bonuses of the given side's pieces for the squares
+// they can move to, excluding squares guarded by the opponent's pawns.
+func (p *Position) mobility(sd Side) int {
+	occ := p.occupied()
+	safe := ^p.occ[sd] &^ p.pawnAttacksBy(sd.Opponent())
+
+	score := 0
+	for _, pt := range []pieceType{pieceKnight, pieceBishop, pieceRook, pieceQueen} {
+		for pcs := p.pieces[sd][pt]; pcs != 0; {
+			n := (pieceAttacks(pt, pcs.pop(), occ) & safe).count()
+			score += mobilityWeights[pt] * (n - mobilityBaselines[pt])
+		}
+	}
+
+	return score
+}
+
+// pawnStructure returns the bonuses of the given side's passed pawns, less
+// the penalties for its doubled and isolated pawns.
+func (p *Position) pawnStructure(sd Side) int {
+	pawns, theirs := p.pieces[sd][piecePawn], p.pieces[sd.Opponent()][piecePawn]
+
+	score := 0
+	for f := range 8 {
+		file := fileA << f
+		n := (pawns & file).count()
+		if n == 0 {
+			continue
+		}
+
+		score -= doubledPawnPenalty * (n - 1)
+		if pawns&adjacentFiles(f) == 0 {
+			score -= isolatedPawnPenalty * n
+		}
+	}
+
+	for bb := pawns; bb != 0; {
+		sq := bb.pop()
+		if theirs&frontSpan(sq, sd) == 0 {
+			score += passedPawnBonuses[relativeRank(sq, sd)]
+		}
+	}
+
+	return score
+}
+
+// kingSafety returns the bonuses for the pawns sheltering the given side's
+// king, less the penalties for attacks on the squares around it, scaled by
+// the game phase.
+func (p *Position) kingSafety(sd Side, ph int) int {
+	k := p.kingSquare(sd)
+	if k < 0 {
+		return 0
+	}
+
+	them := sd.Opponent()
+	pawns := p.pieces[sd][piecePawn]
+	fwd := 8
+	if sd == sideBlack {
+		fwd = -8
+	}
+
+	// pawns in front of a king on its first two ranks shelter it
+	score := 0
+	if relativeRank(k, sd) <= 1 {
+		for f := max(k%8-1, 0); f <= min(k%8+1, 7); f++ {
+			sq := (k/8)*8 + f
+			switch {
+			case pawns.has(sq + fwd):
+				score += shelterBonus
+			case pawns.has(sq + 2*fwd):
+				score += shelterFarBonus
+			default:
+				score -= shelterOpenPenalty
+			}
+		}
+	}
+
+	zone := kingAttacks[k] | squareBit(k)
+	occ := p.occupied()
+	for _, pt := range []pieceType{pieceKnight, pieceBishop, pieceRook, pieceQueen} {
+		for pcs := p.pieces[them][pt]; pcs != 0; {
+			score -= kingAttackWeights[pt] * (pieceAttacks(pt, pcs.pop(), occ) & zone).count()
+		}
+	}
+
+	return score * ph / totalPhase
+}
+
+// adjacentFiles returns the squares of the files either side of a file.
+func adjacentFiles(f int) bitboard {
+	var bb bitboard
+	if f > 0 {
+		bb |= fileA << (f - 1)
+	}
+
+	if f < 7 {
+		bb |= fileA << (f + 1)
+	}
+
+	return bb
+}
+
+// frontSpan returns the squares in front of a pawn of the given side, on its
+// own and adjacent files, that an opposing pawn could stop it from.
+func frontSpan(sq int, sd Side) bitboard {
+	files := fileA<<(sq%8) | adjacentFiles(sq%8)
+	if sd == sideWhite {
+		return files & (^bitboard(0) << (8 * (sq/8 + 1)))
+	}
+
+	return files & (squareBit(8*(sq/8)) - 1)
+}
+
+// relativeRank returns the rank of a square as counted from the given side,
+// from zero (its back rank) to seven.
+func relativeRank(sq int, sd Side) int {
+	if sd == sideWhite {
+		return sq / 8
+	}
+
+	return 7 - sq/8
+}
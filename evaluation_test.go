@@ -0,0 +1,115 @@
+package chess
+
+import "testing"
+
+// mustEvaluate loads a FEN and evaluates it.
+func mustEvaluate(t *testing.T, fen string) Evaluation {
+	t.Helper()
+
+	client, err := CreateAlgebraicGameClientFromFEN(fen)
+	if err != nil {
+		t.Fatalf("failed to load FEN: %v", err)
+	}
+
+	return client.Evaluate()
+}
+
+func TestEvaluateInitialPosition(t *testing.T) {
+	if ev := mustEvaluate(t, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"); ev != (Evaluation{}) {
+		t.Fatalf("expected a balanced evaluation, got %+v", ev)
+	}
+}
+
+func TestEvaluateSymmetry(t *testing.T) {
+	// the mirrored position swaps the colours, negating every term
+	ev := mustEvaluate(t, "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1")
+	mrr := mustEvaluate(t, "r2q1rk1/pP1p2pp/Q4n2/bbp1p3/Np6/1B3NBn/pPPP1PPP/R3K2R b KQ - 0 1")
+
+	want := Evaluation{
+		KingSafety:    -ev.KingSafety,
+		Material:      -ev.Material,
+		Mobility:      -ev.Mobility,
+		PawnStructure: -ev.PawnStructure,
+		PieceSquares:  -ev.PieceSquares,
+		Total:         -ev.Total,
+	}
+	if mrr != want {
+		t.Fatalf("expected %+v, got %+v", want, mrr)
+	}
+}
+
+func TestEvaluateTerms(t *testing.T) {
+	t.Run("material", func(t *testing.T) {
+		if ev := mustEvaluate(t, "rnb1kbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"); ev.Material != 900 {
+			t.Fatalf("expected a queen's advantage, got %d", ev.Material)
+		}
+	})
+
+	t.Run("total", func(t *testing.T) {
+		ev := mustEvaluate(t, generatorPositions[1].fen)
+		if sum := ev.KingSafety + ev.Material + ev.Mobility + ev.PawnStructure + ev.PieceSquares; ev.Total != sum {
+			t.Fatalf("expected the total to be the sum of the terms (%d), got %d", sum, ev.Total)
+		}
+	})
+
+	t.Run("doubled and isolated pawns", func(t *testing.T) {
+		// white's pawns are doubled and isolated, black's is isolated, and none are passed
+		ev := mustEvaluate(t, "4k3/1p6/8/8/8/2P5/2P5/4K3 w - - 0 1")
+		if want := -doubledPawnPenalty - 2*isolatedPawnPenalty + isolatedPawnPenalty; ev.PawnStructure != want {
+			t.Fatalf("expected pawn structure %d, got %d", want, ev.PawnStructure)
+		}
+	})
+
+	t.Run("passed pawns", func(t *testing.T) {
+		far := mustEvaluate(t, "4k3/1P6/8/8/8/8/8/4K3 w - - 0 1")
+		near := mustEvaluate(t, "4k3/8/8/8/8/8/1P6/4K3 w - - 0 1")
+		if far.PawnStructure <= near.PawnStructure {
+			t.Fatalf("expected an advanced passed pawn to be worth more (%d, %d)", far.PawnStructure, near.PawnStructure)
+		}
+	})
+
+	t.Run("mobility", func(t *testing.T) {
+		ev := mustEvaluate(t, "4k3/8/8/8/3N4/8/8/N3K3 w - - 0 1")
+		if want := mobilityWeights[pieceKnight]*(8-mobilityBaselines[pieceKnight]) + mobilityWeights[pieceKnight]*(2-mobilityBaselines[pieceKnight]); ev.Mobility != want {
+			t.Fatalf("expected mobility %d, got %d", want, ev.Mobility)
+		}
+	})
+
+	t.Run("king safety", func(t *testing.T) {
+		sheltered := mustEvaluate(t, "rnbq1rk1/ppppbppp/4pn2/8/8/4PN2/PPPPBPPP/RNBQ1RK1 w - - 0 1")
+		exposed := mustEvaluate(t, "rnbq1rk1/ppppbppp/4pn2/8/8/4PN1P/PPPPBPK1/RNBQ1R2 w - - 0 1")
+		if sheltered.KingSafety != 0 || exposed.KingSafety >= 0 {
+			t.Fatalf("expected an exposed king to be penalised (%d, %d)", sheltered.KingSafety, exposed.KingSafety)
+		}
+	})
+}
+
+func TestPositionEvaluate(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN(generatorPositions[1].fen)
+	if err != nil {
+		t.Fatalf("failed to load FEN: %v", err)
+	}
+
+	pos := client.Position()
+	if got, want := pos.Evaluate(), client.Evaluate(); got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+
+	// the internal score is relative to the side to move
+	mustMove(t, client, "Nxf7")
+	if got, want := client.Position().evaluate(), -client.Evaluate().Total; got != want {
+		t.Fatalf("expected %d for black, got %d", want, got)
+	}
+}
+
+func BenchmarkEvaluate(b *testing.B) {
+	client, err := CreateAlgebraicGameClientFromFEN(generatorPositions[1].fen)
+	if err != nil {
+		b.Fatalf("failed to load FEN: %v", err)
+	}
+	pos := client.Position()
+
+	for b.Loop() {
+		pos.Evaluate()
+	}
+}
@@ -1,36 +1,20 @@
 package chess
 
-import "sync"
-
-type eventSubscriber struct {
-	ch  chan interface{}
-	ack chan struct{}
-}
-
-func newEventSubscriber(handler func(any)) *eventSubscriber {
-	sub := &eventSubscriber{
-		ch:  make(chan interface{}),
-		ack: make(chan struct{}),
-	}
-
-	go func() {
-		for data := range sub.ch {
-			handler(data)
-			sub.ack <- struct{}{}
-		}
-	}()
-
-	return sub
-}
+import (
+	"slices"
+	"sync"
+)
 
+// eventHub dispatches events synchronously to the handlers subscribed to them,
+// in the order they subscribed.
 type eventHub struct {
 	mu        sync.RWMutex
-	listeners map[string][]*eventSubscriber
+	listeners map[string][]func(any)
 }
 
 func newEventHub() *eventHub {
 	return &eventHub{
-		listeners: make(map[string][]*eventSubscriber),
+		listeners: make(map[string][]func(any)),
 	}
 }
 
@@ -40,12 +24,11 @@ func (h *eventHub) emit(e string, dta any) {
 	}
 
 	h.mu.RLock()
-	subs := append([]*eventSubscriber(nil), h.listeners[e]...)
+	hndlrs := slices.Clone(h.listeners[e])
 	h.mu.RUnlock()
 
-	for _, sub := range subs {
-		sub.ch <- dta
-		<-sub.ack
+	for _, hndlr := range hndlrs {
+		hndlr(dta)
 	}
 }
 
@@ -54,19 +37,38 @@ func (h *eventHub) on(e string, hndlr func(any)) {
 		return
 	}
 
-	sub := newEventSubscriber(hndlr)
 	h.mu.Lock()
-	h.listeners[e] = append(h.listeners[e], sub)
+	h.listeners[e] = append(h.listeners[e], hndlr)
 	h.mu.Unlock()
 }
 
+// GameOverEvent is emitted when a game ends.
+type GameOverEvent struct {
+	Result      Result
+	Termination Termination
+}
+
 type KingThreatEvent struct {
 	AttackingSquare *Square
 	KingSquare      *Square
 }
 
+// MoveRuleEvent is emitted when the halfmove clock (the number of halfmoves
+// since the last capture or pawn move) reaches the fifty-move or
+// seventy-five-move threshold.
+type MoveRuleEvent struct {
+	HalfmoveClock int
+}
+
+// RepetitionEvent is emitted when the current position has occurred at
+// least three times (a draw may be claimed) or five times (the game is drawn).
+type RepetitionEvent struct {
+	Count int
+}
+
 type MoveEvent struct {
 	Algebraic              string
+	Annotation             *Annotation
 	CapturedPiece          *Piece
 	Castle                 bool
 	EnPassant              bool
@@ -77,7 +79,12 @@ type MoveEvent struct {
 	RookSource             *Square
 	RookDestination        *Square
 	EnPassantCaptureSquare *Square
-	hashCode               string
+	hashCode               uint64
+	prevCstl               castleRights
+	prevEnP                *Square
+	prevFmn                int
+	prevHmc                int
+	prevKey                uint64
 	prevMoveCount          int
 	simulate               bool
 	undone                 bool
@@ -2,12 +2,18 @@ package chess
 
 // GameStatus represents the state of the game at a certain point in time.
 type GameStatus struct {
-	Game         *Game                   // The current game state.
-	IsCheck      bool                    // True if the current player is in check.
-	IsCheckmate  bool                    // True if the current player is in checkmate.
-	IsRepetition bool                    // True if the current board state is a result of repetition.
-	IsStalemate  bool                    // True if the game is a stalemate.
-	NotatedMoves map[string]notationMove // A map of all valid moves in algebraic notation.
+	Game                   *Game                   // The current game state.
+	IsCheck                bool                    // True if the current player is in check.
+	IsCheckmate            bool                    // True if the current player is in checkmate.
+	IsFiftyMoveDraw        bool                    // True if a draw may be claimed under the fifty-move rule.
+	IsFivefoldRepetition   bool                    // True if the current position has occurred five times and the game is drawn.
+	IsInsufficientMaterial bool                    // True if neither player has the material to checkmate, so the game is drawn.
+	IsRepetition           bool                    // True if the current position has occurred three times and a draw may be claimed.
+	IsSeventyFiveMoveDraw  bool                    // True if the game is drawn under the seventy-five-move rule.
+	IsStalemate            bool                    // True if the game is a stalemate.
+	NotatedMoves           map[string]notationMove // A map of all valid moves in algebraic notation.
+	Result                 Result                  // The outcome of the game, or ResultOngoing.
+	Termination            Termination             // The reason the game ended, or TerminationNone.
 }
 
 // Side returns the side of the player who made the last move.
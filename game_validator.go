@@ -11,45 +11,176 @@ type attackContext struct {
 }
 
 type validationResult struct {
-	IsCheck      bool
-	IsCheckmate  bool
-	IsRepetition bool
-	IsStalemate  bool
-	ValidMoves   []potentialMoves
+	IsCheck                bool
+	IsCheckmate            bool
+	IsFiftyMoveDraw        bool
+	IsFivefoldRepetition   bool
+	IsInsufficientMaterial bool
+	IsRepetition           bool
+	IsSeventyFiveMoveDraw  bool
+	IsStalemate            bool
+	ValidMoves             []potentialMoves
 }
 
+const (
+	fiftyMoveRuleHalfmoves       = 100 // fiftyMoveRuleHalfmoves is the halfmove clock at which a draw may be claimed.
+	seventyFiveMoveRuleHalfmoves = 150 // seventyFiveMoveRuleHalfmoves is the halfmove clock at which the game is drawn.
+	threefoldRepetitions         = 3   // threefoldRepetitions is the number of occurrences at which a draw may be claimed.
+	fivefoldRepetitions          = 5   // fivefoldRepetitions is the number of occurrences at which the game is drawn.
+)
+
 func CreateGameValidator(g *Game) *gameValidator {
 	return &gameValidator{game: g}
 }
 
-func (gv *gameValidator) findKingSquare(sd Side) *Square {
-	squares := gv.game.Board.getSquares(sd)
-	for _, sq := range squares {
-		if sq.Piece != nil && sq.Piece.Type == pieceKing {
-			return sq
+// hasInsufficientMaterial reports whether the given side cannot checkmate its
+// opponent by any sequence of legal moves. A lone king never can; a king and
+// knight can only mate when the opponent has pieces of its own to block with;
+// and bishops that all stand on squares of the same color (with no knights or
+// pawns on the board) can never mate.
+func (gv *gameValidator) hasInsufficientMaterial(sd Side) bool {
+	var bishops, knights, others int
+	for _, sq := range gv.game.Board.getSquares(sd) {
+		switch sq.Piece.Type {
+		case pieceKing:
+		case pieceBishop:
+			bishops++
+		case pieceKnight:
+			knights++
+		default:
+			others++
+		}
+	}
+
+	if others > 0 {
+		return false
+	}
+
+	opp := 0
+	for _, sq := range gv.game.Board.getSquares(sd.Opponent()) {
+		if sq.Piece.Type != pieceKing && sq.Piece.Type != pieceQueen {
+			opp++
+		}
+	}
+
+	if knights > 0 {
+		return knights+bishops == 1 && opp == 0
+	}
+
+	if bishops > 0 {
+		light, dark := false, false
+		for _, sq := range gv.game.Board.Squares {
+			if sq.Piece == nil {
+				continue
+			}
+
+			switch sq.Piece.Type {
+			case pieceBishop:
+				if (int(sq.File-'a')+sq.Rank)%2 == 0 {
+					light = true
+				} else {
+					dark = true
+				}
+			case pieceKnight, piecePawn:
+				return false
+			}
 		}
+
+		return !light || !dark
 	}
-	return nil
+
+	return true
 }
 
-func (gv *gameValidator) isRepetition() bool {
-	counts := map[string]int{}
-	for _, mv := range gv.game.MoveHistory {
-		counts[mv.hashCode]++
-		if counts[mv.hashCode] == 3 {
-			return true
+// checkMoveRules reports whether a draw may be claimed under the fifty-move
+// rule and whether the game is drawn under the seventy-five-move rule. A
+// checkmate delivered on the final move takes precedence over both rules.
+func (gv *gameValidator) checkMoveRules(result *validationResult) {
+	if result.IsCheckmate {
+		return
+	}
+
+	hmc := gv.game.hmc
+	result.IsFiftyMoveDraw = hmc >= fiftyMoveRuleHalfmoves
+	result.IsSeventyFiveMoveDraw = hmc >= seventyFiveMoveRuleHalfmoves
+
+	if result.IsSeventyFiveMoveDraw {
+		gv.game.emit("seventyFiveMoveRule", &MoveRuleEvent{HalfmoveClock: hmc})
+		return
+	}
+
+	if result.IsFiftyMoveDraw {
+		gv.game.emit("fiftyMoveRule", &MoveRuleEvent{HalfmoveClock: hmc})
+	}
+}
+
+// canCaptureEnPassant reports whether any of the valid moves is an en passant capture.
+func (gv *gameValidator) canCaptureEnPassant(validMoves []potentialMoves) bool {
+	if gv.game.enP == nil {
+		return false
+	}
+
+	for _, pm := range validMoves {
+		if pm.origin == nil || pm.origin.Piece == nil || pm.origin.Piece.Type != piecePawn {
+			continue
+		}
+
+		for _, dest := range pm.destinationSquares {
+			if dest == gv.game.enP {
+				return true
+			}
 		}
 	}
+
 	return false
 }
 
+// checkRepetition records the hash of the current position and counts how
+// many times the position has occurred, including the starting position.
+func (gv *gameValidator) checkRepetition(result *validationResult) {
+	hash := gv.game.getHashCode(gv.canCaptureEnPassant(result.ValidMoves))
+
+	if len(gv.game.MoveHistory) == 0 {
+		gv.game.init = hash
+	} else {
+		gv.game.MoveHistory[len(gv.game.MoveHistory)-1].hashCode = hash
+	}
+
+	count := 0
+	if gv.game.init == hash {
+		count++
+	}
+
+	for _, mv := range gv.game.MoveHistory {
+		if mv.hashCode == hash {
+			count++
+		}
+	}
+
+	result.IsRepetition = count >= threefoldRepetitions
+	result.IsFivefoldRepetition = count >= fivefoldRepetitions
+
+	if result.IsFivefoldRepetition {
+		gv.game.emit("fivefoldRepetition", &RepetitionEvent{Count: count})
+		return
+	}
+
+	if result.IsRepetition {
+		gv.game.emit("repetition", &RepetitionEvent{Count: count})
+	}
+}
+
 func (gv *gameValidator) Check() (*validationResult, error) {
 	result := &validationResult{
-		IsCheck:      false,
-		IsCheckmate:  false,
-		IsRepetition: false,
-		IsStalemate:  false,
-		ValidMoves:   []potentialMoves{},
+		IsCheck:                false,
+		IsCheckmate:            false,
+		IsFiftyMoveDraw:        false,
+		IsFivefoldRepetition:   false,
+		IsInsufficientMaterial: false,
+		IsRepetition:           false,
+		IsSeventyFiveMoveDraw:  false,
+		IsStalemate:            false,
+		ValidMoves:             []potentialMoves{},
 	}
 
 	bv := CreateBoardValidator(gv.game)
@@ -58,14 +189,15 @@ func (gv *gameValidator) Check() (*validationResult, error) {
 		return nil, err
 	}
 
-	kingSquare := gv.findKingSquare(gv.game.getCurrentSide())
-	isAttacked := bv.isSquareAttacked(kingSquare)
+	isAttacked := bv.isCheck()
 
 	result.IsCheck = isAttacked && len(validMoves) > 0
 	result.IsCheckmate = isAttacked && len(validMoves) == 0
 	result.IsStalemate = !isAttacked && len(validMoves) == 0
 	result.ValidMoves = validMoves
-	result.IsRepetition = gv.isRepetition()
+	result.IsInsufficientMaterial = gv.hasInsufficientMaterial(sideWhite) && gv.hasInsufficientMaterial(sideBlack)
+	gv.checkRepetition(result)
+	gv.checkMoveRules(result)
 
 	return result, nil
 }
@@ -1,8 +1,6 @@
 package chess
 
 import (
-	"crypto/md5"
-	"encoding/base64"
 	"math"
 	"strconv"
 	"strings"
@@ -18,11 +16,17 @@ type Game struct {
 	// MoveHistory is a chronological record of all moves made in the game.
 	MoveHistory []*MoveEvent
 
-	cstl string
+	c960 bool
+	cstl castleRights
 	enP  *Square
 	ev   *eventHub
 	hmc  int
 	fmn  int
+	init uint64
+	key  uint64
+	res  Result
+	shrd bool
+	term Termination
 	wf   bool
 }
 
@@ -32,7 +36,7 @@ func createGame(wf ...bool) *Game {
 		Board:          createBoard(),
 		CaptureHistory: []*Piece{},
 		MoveHistory:    []*MoveEvent{},
-		cstl:           "KQkq", // default is both Kings can castle King and Queen side
+		cstl:           standardCastleRights(), // default is both Kings can castle King and Queen side
 		ev:             newEventHub(),
 		fmn:            1,    // default is first move
 		wf:             true, // default is white moves first
@@ -44,6 +48,7 @@ func createGame(wf ...bool) *Game {
 	}
 
 	g.hookBoardEvents()
+	g.key = newPosition(g).key
 
 	return g
 }
@@ -77,7 +82,7 @@ func (g *Game) fen() string {
 	b.WriteRune(' ')
 
 	// 3. Castling availability
-	b.WriteString(g.cstl)
+	b.WriteString(g.cstl.fen(g.Board, g.shrd))
 
 	// add a space
 	b.WriteRune(' ')
@@ -121,35 +126,50 @@ func (g *Game) getCurrentSide() Side {
 	return sideWhite
 }
 
-// getHashCode generates a unique hash for the current board position.
-// This is used to detect position repetitions for threefold repetition draws.
-func (g *Game) getHashCode() string {
-	var builder strings.Builder
+// enPassantPawn returns the pawn that can be captured en passant on the
+// current en passant target square, if any.
+func (g *Game) enPassantPawn() *Piece {
+	if g.enP == nil {
+		return nil
+	}
 
-	for _, sq := range g.Board.Squares {
-		if sq.Piece != nil {
-			if builder.Len() > 0 {
-				builder.WriteRune('-')
-			}
+	rnk := g.enP.Rank + 1
+	if g.enP.Rank == 6 {
+		rnk = g.enP.Rank - 1
+	}
 
-			builder.WriteRune(sq.File)
-			builder.WriteString(strconv.Itoa(sq.Rank))
-			sideMarker := "b"
-			if sq.Piece.Side == sideWhite {
-				sideMarker = "w"
-			}
+	sq := g.Board.GetSquare(g.enP.File, rnk)
+	if sq == nil || sq.Piece == nil || sq.Piece.Type != piecePawn {
+		return nil
+	}
 
-			builder.WriteString(sideMarker)
-			builder.WriteString(sq.Piece.Notation)
-			if sq.Piece.Type == piecePawn {
-				builder.WriteString("p")
-			}
-		}
+	return sq.Piece
+}
+
+// getHashCode returns the Zobrist key of the current position. Following
+// the FIDE definition of a repeated position, the key covers the piece
+// placement, the side to move and the castling rights, along with the file of
+// the en passant target when an en passant capture is actually possible (ep).
+// This is used to detect position repetitions for threefold and fivefold
+// repetition draws.
+func (g *Game) getHashCode(ep bool) uint64 {
+	if ep && g.enP != nil {
+		return g.key ^ zobristEnPassant[g.enP.File-'a']
+	}
+
+	return g.key
+}
+
+// Hash returns the 64-bit Zobrist key of the current position. Positions that
+// are the same under the FIDE repetition rules (the same pieces on the same
+// squares, side to move, castling rights and possible en passant captures)
+// have the same key, which is stable across programs using the package.
+func (g *Game) Hash() uint64 {
+	if g.enP == nil {
+		return g.key
 	}
 
-	sum := builder.String()
-	hash := md5.Sum([]byte(sum))
-	return base64.StdEncoding.EncodeToString(hash[:])
+	return g.getHashCode(newPosition(g).canCaptureEnPassant())
 }
 
 // hookBoardEvents sets up listeners for events from the Board object.
@@ -195,7 +215,14 @@ func (g *Game) hookBoardEvents() {
 			g.CaptureHistory = g.CaptureHistory[:len(g.CaptureHistory)-1]
 		}
 
-		g.Board.LastMovedPiece = nil
+		// restore the castling rights, en passant target and clocks
+		g.cstl = mv.prevCstl
+		g.enP = mv.prevEnP
+		g.fmn = mv.prevFmn
+		g.hmc = mv.prevHmc
+		g.key = mv.prevKey
+
+		g.Board.LastMovedPiece = g.enPassantPawn()
 		if len(g.MoveHistory) > 0 {
 			g.Board.LastMovedPiece = g.MoveHistory[len(g.MoveHistory)-1].Piece
 		}
@@ -234,6 +261,9 @@ func (g *Game) promote(sq *Square, p *Piece) (*Square, error) {
 		g.MoveHistory[len(g.MoveHistory)-1].Promotion = true
 	}
 
+	// the promoted piece replaces the pawn
+	g.key ^= zobristPiece(newPiece(piecePawn, p.Side), target) ^ zobristPiece(p, target)
+
 	return target, nil
 }
 
@@ -243,8 +273,14 @@ func (g *Game) recordMove(mv *MoveEvent) {
 		return
 	}
 
+	// keep the state that the move changes so that it can be undone
+	mv.prevCstl = g.cstl
+	mv.prevEnP = g.enP
+	mv.prevFmn = g.fmn
+	mv.prevHmc = g.hmc
+	mv.prevKey = g.key
+
 	// create the move history entry
-	mv.hashCode = g.getHashCode()
 	g.MoveHistory = append(g.MoveHistory, mv)
 	if mv.CapturedPiece != nil {
 		g.CaptureHistory = append(g.CaptureHistory, mv.CapturedPiece)
@@ -265,41 +301,37 @@ func (g *Game) recordMove(mv *MoveEvent) {
 	// update cstl (castle availability) if appropriate
 	// if the King has moved, casteling King and Queen side is disabled
 	if mv.Piece.Type == pieceKing {
-		if mv.Piece.Side == sideWhite {
-			g.cstl = strings.ReplaceAll(g.cstl, "K", "")
-			g.cstl = strings.ReplaceAll(g.cstl, "Q", "")
-		}
-
-		if mv.Piece.Side == sideBlack {
-			g.cstl = strings.ReplaceAll(g.cstl, "k", "")
-			g.cstl = strings.ReplaceAll(g.cstl, "q", "")
-		}
+		g.cstl[mv.Piece.Side] = [2]rune{}
 	}
 
-	// if a Rook has moved, check the previous square position
-	// to determine which (King or Queen) castle option to remove
+	// if a Rook has moved or been captured, remove the castle option
+	// that depended upon it
 	if mv.Piece.Type == pieceRook {
-		if mv.PrevSquare.File == 'a' {
-			if mv.PrevSquare.Rank == 1 {
-				g.cstl = strings.ReplaceAll(g.cstl, "Q", "")
-			}
-
-			if mv.PrevSquare.Rank == 8 {
-				g.cstl = strings.ReplaceAll(g.cstl, "q", "")
-			}
-		}
+		g.cstl.revoke(mv.PrevSquare)
+	}
 
-		if mv.PrevSquare.File == 'h' {
-			if mv.PrevSquare.Rank == 1 {
-				g.cstl = strings.ReplaceAll(g.cstl, "K", "")
-			}
+	if mv.CapturedPiece != nil && mv.CapturedPiece.Type == pieceRook {
+		g.cstl.revoke(mv.PostSquare)
+	}
 
-			if mv.PrevSquare.Rank == 8 {
-				g.cstl = strings.ReplaceAll(g.cstl, "k", "")
-			}
+	// update the Zobrist key for the pieces moved and captured, the castling
+	// rights and the side to move
+	g.key ^= zobristPiece(mv.Piece, mv.PrevSquare) ^ zobristPiece(mv.Piece, mv.PostSquare)
+	if mv.CapturedPiece != nil {
+		cs := mv.PostSquare
+		if mv.EnPassantCaptureSquare != nil {
+			cs = mv.EnPassantCaptureSquare
 		}
+		g.key ^= zobristPiece(mv.CapturedPiece, cs)
 	}
 
+	if mv.Castle && mv.RookSource != nil && mv.RookDestination != nil {
+		rook := mv.RookDestination.Piece
+		g.key ^= zobristPiece(rook, mv.RookSource) ^ zobristPiece(rook, mv.RookDestination)
+	}
+
+	g.key ^= zobristCastleRights(mv.prevCstl) ^ zobristCastleRights(g.cstl) ^ zobristSide
+
 	// unassign enP (enpassant target), and reset if appropriate
 	g.enP = nil
 	if mv.Piece.Type == piecePawn {
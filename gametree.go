@@ -0,0 +1,295 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// GameNode is a position within a GameTree, reached by playing Move from its
+// Parent. The first child continues the main line and any further children
+// are alternative moves (variations).
+type GameNode struct {
+	// Annotation holds the comments, NAGs and embedded commands of the move.
+	Annotation *Annotation
+	// Children are the moves played from this position; Children[0] is the main line.
+	Children []*GameNode
+	// FEN is the Forsyth-Edwards Notation of the position.
+	FEN string
+	// Move is the move leading to this position in Standard Algebraic Notation (empty for the root).
+	Move string
+	// Parent is the position before Move was played (nil for the root).
+	Parent *GameNode
+	// Ply is the number of halfmoves played from the root of the tree.
+	Ply int
+	// Status is the status of the game at this position.
+	Status *GameStatus
+	// UCI is the move leading to this position in UCI long algebraic notation (empty for the root).
+	UCI string
+}
+
+// GameTree records a game along with its variations. Every move is validated
+// by an AlgebraicGameClient, which always reflects the position of the
+// current node.
+type GameTree struct {
+	base    int
+	client  *AlgebraicGameClient
+	current *GameNode
+	root    *GameNode
+}
+
+// CreateGameTree creates a game tree whose root is the current position of
+// the given client. The tree takes over the client's navigation: moves should
+// be made through the tree from then on.
+func CreateGameTree(c *AlgebraicGameClient) (*GameTree, error) {
+	if c == nil {
+		return nil, errors.New("client is required to create a game tree")
+	}
+
+	status, err := c.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	root := &GameNode{
+		Annotation: &Annotation{},
+		Children:   []*GameNode{},
+		FEN:        c.FEN(),
+		Status:     status,
+	}
+
+	return &GameTree{
+		base:    c.Ply(),
+		client:  c,
+		current: root,
+		root:    root,
+	}, nil
+}
+
+// Client returns the client used to validate moves, positioned at the current node.
+func (t *GameTree) Client() *AlgebraicGameClient {
+	return t.client
+}
+
+// Current returns the node at the tree's cursor.
+func (t *GameTree) Current() *GameNode {
+	return t.current
+}
+
+// Root returns the starting position of the tree.
+func (t *GameTree) Root() *GameNode {
+	return t.root
+}
+
+// MainLine returns the nodes of the main line, from the first move onwards.
+func (t *GameTree) MainLine() []*GameNode {
+	var line []*GameNode
+	for n := t.root; len(n.Children) > 0; n = n.Children[0] {
+		line = append(line, n.Children[0])
+	}
+
+	return line
+}
+
+// Move plays a move in algebraic or coordinate notation from the current
+// node and moves the cursor to the resulting node. When the move has already
+// been recorded the existing node is reused, otherwise it is added as the
+// main line (if the node has no children yet) or as a new variation.
+func (t *GameTree) Move(ntn string) (*GameNode, error) {
+	key, ok := t.client.lookup(ntn)
+	if !ok {
+		return nil, fmt.Errorf("notation is invalid (%s)", ntn)
+	}
+
+	uci := t.client.uciFromKey(key)
+	for _, child := range t.current.Children {
+		if child.UCI == uci {
+			return child, t.GoTo(child)
+		}
+	}
+
+	res, err := t.client.Move(key)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := t.client.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	n := &GameNode{
+		Annotation: &Annotation{},
+		Children:   []*GameNode{},
+		FEN:        t.client.FEN(),
+		Move:       res.Move.Algebraic,
+		Parent:     t.current,
+		Ply:        t.current.Ply + 1,
+		Status:     status,
+		UCI:        uci,
+	}
+	t.current.Children = append(t.current.Children, n)
+	t.current = n
+
+	// the move in the game history shares the node's annotation
+	res.Move.Annotation = n.Annotation
+
+	return n, nil
+}
+
+// GoTo moves the cursor to the given node, replaying the moves that lead to it.
+func (t *GameTree) GoTo(n *GameNode) error {
+	if !t.contains(n) {
+		return errors.New("node does not belong to the game tree")
+	}
+
+	// return to the position shared by both lines...
+	common := t.current
+	for !isAncestor(common, n) {
+		common = common.Parent
+	}
+
+	if err := t.client.GoToPly(t.base + common.Ply); err != nil {
+		return err
+	}
+
+	// ...then replay the moves leading to the node. The tree keeps its own
+	// lines, so the moves undone by the client are not kept to be redone, and
+	// recorded moves are replayed as Redo does, even once the game has ended.
+	path := []*GameNode{}
+	for p := n; p != common; p = p.Parent {
+		path = append(path, p)
+	}
+
+	t.client.redo = nil
+	for i := len(path) - 1; i >= 0; i-- {
+		res, err := t.client.play(path[i].UCI)
+		if err != nil {
+			return err
+		}
+		res.Move.Annotation = path[i].Annotation
+	}
+
+	t.current = n
+
+	return nil
+}
+
+// Back moves the cursor to the parent of the current node.
+func (t *GameTree) Back() error {
+	if t.current.Parent == nil {
+		return errors.New("already at the root of the game tree")
+	}
+
+	return t.GoTo(t.current.Parent)
+}
+
+// Forward moves the cursor along the main line from the current node.
+func (t *GameTree) Forward() error {
+	if len(t.current.Children) == 0 {
+		return errors.New("already at the end of the line")
+	}
+
+	return t.GoTo(t.current.Children[0])
+}
+
+// Promote moves the given node one place up among its siblings, so that a
+// variation promoted to the first place becomes the main line.
+func (t *GameTree) Promote(n *GameNode) error {
+	idx, err := t.siblingIndex(n)
+	if err != nil {
+		return err
+	}
+
+	if idx == 0 {
+		return errors.New("node is already the main line")
+	}
+
+	sbl := n.Parent.Children
+	sbl[idx-1], sbl[idx] = sbl[idx], sbl[idx-1]
+
+	return nil
+}
+
+// PromoteToMainLine promotes the given node, and each of its ancestors, to
+// the first place among their siblings so that the node is on the main line.
+func (t *GameTree) PromoteToMainLine(n *GameNode) error {
+	if !t.contains(n) {
+		return errors.New("node does not belong to the game tree")
+	}
+
+	for p := n; p.Parent != nil; p = p.Parent {
+		sbl := p.Parent.Children
+		idx := slices.Index(sbl, p)
+		copy(sbl[1:idx+1], sbl[:idx])
+		sbl[0] = p
+	}
+
+	return nil
+}
+
+// Demote moves the given node one place down among its siblings.
+func (t *GameTree) Demote(n *GameNode) error {
+	idx, err := t.siblingIndex(n)
+	if err != nil {
+		return err
+	}
+
+	sbl := n.Parent.Children
+	if idx == len(sbl)-1 {
+		return errors.New("node is already the last variation")
+	}
+
+	sbl[idx+1], sbl[idx] = sbl[idx], sbl[idx+1]
+
+	return nil
+}
+
+// Delete removes the given node and every move that follows it. When the
+// cursor is on a deleted node it moves to the parent of the given node.
+func (t *GameTree) Delete(n *GameNode) error {
+	idx, err := t.siblingIndex(n)
+	if err != nil {
+		return err
+	}
+
+	if isAncestor(n, t.current) {
+		if err := t.GoTo(n.Parent); err != nil {
+			return err
+		}
+	}
+
+	n.Parent.Children = slices.Delete(n.Parent.Children, idx, idx+1)
+	n.Parent = nil
+
+	return nil
+}
+
+// contains reports whether the node belongs to the tree.
+func (t *GameTree) contains(n *GameNode) bool {
+	return n != nil && isAncestor(t.root, n)
+}
+
+// siblingIndex returns the position of a (non-root) node among its siblings.
+func (t *GameTree) siblingIndex(n *GameNode) (int, error) {
+	if !t.contains(n) {
+		return 0, errors.New("node does not belong to the game tree")
+	}
+
+	if n.Parent == nil {
+		return 0, errors.New("the root of the game tree has no siblings")
+	}
+
+	return slices.Index(n.Parent.Children, n), nil
+}
+
+// isAncestor reports whether a is n or one of its ancestors.
+func isAncestor(a, n *GameNode) bool {
+	for p := n; p != nil; p = p.Parent {
+		if p == a {
+			return true
+		}
+	}
+
+	return false
+}
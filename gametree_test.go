@@ -0,0 +1,198 @@
+package chess
+
+import "testing"
+
+func mustTreeMove(t *testing.T, tree *GameTree, ntn string) *GameNode {
+	t.Helper()
+	n, err := tree.Move(ntn)
+	if err != nil {
+		t.Fatalf("tree.Move(%s) error = %v", ntn, err)
+	}
+	return n
+}
+
+func TestGameTreeVariations(t *testing.T) {
+	tree, err := CreateGameTree(CreateAlgebraicGameClient(AlgebraicClientOptions{}))
+	if err != nil {
+		t.Fatalf("CreateGameTree() error = %v", err)
+	}
+
+	e4 := mustTreeMove(t, tree, "e4")
+	e5 := mustTreeMove(t, tree, "e5")
+	mustTreeMove(t, tree, "Nf3")
+
+	// 1... c5 as a variation of 1... e5
+	if err := tree.GoTo(e4); err != nil {
+		t.Fatalf("GoTo() error = %v", err)
+	}
+	c5 := mustTreeMove(t, tree, "c7c5")
+	nf3 := mustTreeMove(t, tree, "Nf3")
+
+	if c5.Move != "c5" || c5.UCI != "c7c5" || c5.Ply != 2 {
+		t.Fatalf("unexpected node %s (%s) at ply %d", c5.Move, c5.UCI, c5.Ply)
+	}
+	if len(e4.Children) != 2 || e4.Children[0] != e5 || e4.Children[1] != c5 {
+		t.Fatalf("expected e5 as main line and c5 as variation")
+	}
+	if got := tree.Client().FEN(); got != nf3.FEN {
+		t.Fatalf("client FEN mismatch:\n got: %s\nwant: %s", got, nf3.FEN)
+	}
+	if exp := "rnbqkbnr/pp1ppppp/8/2p5/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2"; nf3.FEN != exp {
+		t.Fatalf("node FEN mismatch:\n got: %s\nwant: %s", nf3.FEN, exp)
+	}
+
+	// playing a recorded move reuses its node
+	if err := tree.GoTo(e4); err != nil {
+		t.Fatalf("GoTo() error = %v", err)
+	}
+	if n := mustTreeMove(t, tree, "e5"); n != e5 || len(e4.Children) != 2 {
+		t.Fatalf("expected existing node to be reused")
+	}
+	if got := tree.Client().FEN(); got != e5.FEN {
+		t.Fatalf("client FEN mismatch:\n got: %s\nwant: %s", got, e5.FEN)
+	}
+
+	if err := tree.Promote(c5); err != nil {
+		t.Fatalf("Promote() error = %v", err)
+	}
+	if e4.Children[0] != c5 {
+		t.Fatalf("expected c5 to become the main line")
+	}
+	if err := tree.Promote(c5); err == nil {
+		t.Fatalf("expected promoting the main line to fail")
+	}
+	if line := tree.MainLine(); len(line) != 3 || line[2] != nf3 {
+		t.Fatalf("unexpected main line")
+	}
+
+	if err := tree.Demote(c5); err != nil {
+		t.Fatalf("Demote() error = %v", err)
+	}
+	if e4.Children[0] != e5 {
+		t.Fatalf("expected e5 to return to the main line")
+	}
+	if err := tree.Demote(c5); err == nil {
+		t.Fatalf("expected demoting the last variation to fail")
+	}
+}
+
+func TestGameTreePromoteToMainLine(t *testing.T) {
+	tree, err := CreateGameTree(CreateAlgebraicGameClient(AlgebraicClientOptions{}))
+	if err != nil {
+		t.Fatalf("CreateGameTree() error = %v", err)
+	}
+
+	mustTreeMove(t, tree, "e4")
+	mustTreeMove(t, tree, "e5")
+	if err := tree.GoTo(tree.Root()); err != nil {
+		t.Fatalf("GoTo() error = %v", err)
+	}
+	d4 := mustTreeMove(t, tree, "d4")
+	mustTreeMove(t, tree, "d5")
+	mustTreeMove(t, tree, "c4")
+	if err := tree.Back(); err != nil {
+		t.Fatalf("Back() error = %v", err)
+	}
+	nf3 := mustTreeMove(t, tree, "Nf3")
+
+	if err := tree.PromoteToMainLine(nf3); err != nil {
+		t.Fatalf("PromoteToMainLine() error = %v", err)
+	}
+
+	line := tree.MainLine()
+	if len(line) != 3 || line[0] != d4 || line[2] != nf3 {
+		t.Fatalf("expected 1. d4 d5 2. Nf3 to be the main line")
+	}
+}
+
+func TestGameTreeDelete(t *testing.T) {
+	tree, err := CreateGameTree(CreateAlgebraicGameClient(AlgebraicClientOptions{}))
+	if err != nil {
+		t.Fatalf("CreateGameTree() error = %v", err)
+	}
+
+	e4 := mustTreeMove(t, tree, "e4")
+	e5 := mustTreeMove(t, tree, "e5")
+	mustTreeMove(t, tree, "Nf3")
+
+	if err := tree.Delete(e5); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if tree.Current() != e4 || len(e4.Children) != 0 {
+		t.Fatalf("expected the cursor to move to e4 after deleting its line")
+	}
+	if got := tree.Client().FEN(); got != e4.FEN {
+		t.Fatalf("client FEN mismatch:\n got: %s\nwant: %s", got, e4.FEN)
+	}
+	if err := tree.GoTo(e5); err == nil {
+		t.Fatalf("expected deleted node to be unreachable")
+	}
+	if err := tree.Delete(tree.Root()); err == nil {
+		t.Fatalf("expected deleting the root to fail")
+	}
+}
+
+func TestGameTreeNodeStatus(t *testing.T) {
+	tree, err := CreateGameTree(CreateAlgebraicGameClient(AlgebraicClientOptions{}))
+	if err != nil {
+		t.Fatalf("CreateGameTree() error = %v", err)
+	}
+
+	mustTreeMove(t, tree, "f3")
+	mustTreeMove(t, tree, "e5")
+	g4 := mustTreeMove(t, tree, "g4")
+	mate := mustTreeMove(t, tree, "Qh4#")
+
+	if err := tree.GoTo(g4); err != nil {
+		t.Fatalf("GoTo() error = %v", err)
+	}
+	nc6 := mustTreeMove(t, tree, "Nc6")
+
+	if !mate.Status.IsCheckmate || mate.Status.Result != ResultBlackWins {
+		t.Fatalf("expected checkmate status on the mating node")
+	}
+	if nc6.Status.IsCheckmate || nc6.Status.Result != ResultOngoing {
+		t.Fatalf("expected ongoing status on the variation")
+	}
+	if _, err := tree.Move("Ke3"); err == nil {
+		t.Fatalf("expected illegal moves to be rejected")
+	}
+
+	if err := tree.GoTo(mate); err != nil {
+		t.Fatalf("GoTo() error = %v", err)
+	}
+	if !mustStatus(t, tree.Client(), false).IsCheckmate {
+		t.Fatalf("expected client status to follow the cursor")
+	}
+}
+
+func TestGameTreeNavigatesEndedGame(t *testing.T) {
+	tree, err := CreateGameTree(CreateAlgebraicGameClient(AlgebraicClientOptions{}))
+	if err != nil {
+		t.Fatalf("CreateGameTree() error = %v", err)
+	}
+
+	e4 := mustTreeMove(t, tree, "e4")
+	mustTreeMove(t, tree, "e5")
+	nf3 := mustTreeMove(t, tree, "Nf3")
+
+	if err := tree.Client().Resign(Black); err != nil {
+		t.Fatalf("Resign() error = %v", err)
+	}
+
+	// variations can be added before the resignation...
+	if err := tree.GoTo(e4); err != nil {
+		t.Fatalf("GoTo() error = %v", err)
+	}
+	mustTreeMove(t, tree, "c5")
+
+	// ...and returning to the final position restores it
+	if err := tree.GoTo(nf3); err != nil {
+		t.Fatalf("GoTo() error = %v", err)
+	}
+
+	status := mustStatus(t, tree.Client(), false)
+	if status.Result != ResultWhiteWins || status.Termination != TerminationResignation {
+		t.Fatalf("expected 1-0 by resignation, got %s (%s)", status.Result, status.Termination)
+	}
+}
@@ -0,0 +1,77 @@
+package chess
+
+import "testing"
+
+func TestInsufficientMaterial(t *testing.T) {
+	for _, tc := range []struct {
+		fen string
+		exp bool
+	}{
+		{"4k3/8/8/8/8/8/8/4K3 w - - 0 1", true},
+		{"4k3/8/8/8/8/8/8/4KN2 w - - 0 1", true},
+		{"4k3/8/8/8/8/8/8/4KB2 b - - 0 1", true},
+		{"2b1k3/8/8/8/8/8/8/4KB2 w - - 0 1", true},
+		{"4kb2/8/8/8/8/8/8/4KB2 w - - 0 1", false},
+		{"4k3/8/8/8/8/8/8/2B1KB2 w - - 0 1", false},
+		{"4k3/8/8/8/8/8/8/3BKB2 w - - 0 1", true},
+		{"4kn2/8/8/8/8/8/8/4KN2 w - - 0 1", false},
+		{"4k3/8/8/8/8/8/8/3NKN2 w - - 0 1", false},
+		{"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1", false},
+		{"4k3/8/8/8/8/8/8/4KR2 w - - 0 1", false},
+	} {
+		client, err := CreateAlgebraicGameClientFromFEN(tc.fen)
+		if err != nil {
+			t.Fatalf("fromFEN(%s) failed: %v", tc.fen, err)
+		}
+
+		if got := mustStatus(t, client, false).IsInsufficientMaterial; got != tc.exp {
+			t.Fatalf("IsInsufficientMaterial for %s = %v, want %v", tc.fen, got, tc.exp)
+		}
+	}
+}
+
+func TestInsufficientMaterialAfterCapture(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN("4k3/8/8/8/8/8/3q4/4K3 w - - 0 1")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	if mustStatus(t, client, false).IsInsufficientMaterial {
+		t.Fatalf("expected sufficient material before the capture")
+	}
+
+	mustMove(t, client, "Kxd2")
+
+	if !mustStatus(t, client, false).IsInsufficientMaterial {
+		t.Fatalf("expected bare kings to be insufficient material")
+	}
+}
+
+func TestHasInsufficientMaterialForTimeout(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN("4k3/8/8/8/8/8/3r4/4KN2 w - - 0 1")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	if mustStatus(t, client, false).IsInsufficientMaterial {
+		t.Fatalf("expected the position to have sufficient material")
+	}
+
+	// a knight cannot be forced to mate a bare king, but can mate when the
+	// opponent's own pieces block the king
+	if client.HasInsufficientMaterial(White) {
+		t.Fatalf("expected white knight to have mating chances against a rook")
+	}
+	if client.HasInsufficientMaterial(Black) {
+		t.Fatalf("expected black rook to have mating material")
+	}
+
+	client, err = CreateAlgebraicGameClientFromFEN("4k3/8/8/8/8/8/8/4KN1R w - - 0 1")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	if !client.HasInsufficientMaterial(Black) {
+		t.Fatalf("expected a lone king to have insufficient material")
+	}
+}
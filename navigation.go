@@ -0,0 +1,85 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+)
+
+// undone keeps the client's move stack in step with the game history when a
+// move is undone, whether through Undo or a *moveResult, so that the move can
+// later be redone, and then recalculates the game status.
+func (c *AlgebraicGameClient) undone(data interface{}) {
+	if mv, ok := data.(*MoveEvent); ok && len(c.results) > len(c.game.MoveHistory) {
+		c.results = c.results[:len(c.game.MoveHistory)]
+		c.redo = append(c.redo, mv.Algebraic)
+	}
+
+	_ = c.update()
+}
+
+// Ply returns the number of halfmoves played to reach the current position.
+func (c *AlgebraicGameClient) Ply() int {
+	return len(c.game.MoveHistory)
+}
+
+// Undo reverts the last move played. The move can be replayed with Redo until
+// a different move is made.
+func (c *AlgebraicGameClient) Undo() error {
+	if len(c.results) == 0 {
+		return errors.New("there are no moves to undo")
+	}
+
+	c.results[len(c.results)-1].Undo()
+
+	return nil
+}
+
+// Redo replays the most recently undone move.
+func (c *AlgebraicGameClient) Redo() (*moveResult, error) {
+	if len(c.redo) == 0 {
+		return nil, errors.New("there are no moves to redo")
+	}
+
+	san := c.redo[len(c.redo)-1]
+	res, err := c.play(san)
+	if err != nil {
+		return nil, err
+	}
+
+	c.redo = c.redo[:len(c.redo)-1]
+
+	return res, nil
+}
+
+// GoToPly undoes or redoes moves until n halfmoves have been played, where n
+// is between 0 (the starting position) and the number of moves played plus
+// the number of moves that can be redone.
+func (c *AlgebraicGameClient) GoToPly(n int) error {
+	if n < 0 || n > len(c.results)+len(c.redo) {
+		return fmt.Errorf("ply %d is out of range (0-%d)", n, len(c.results)+len(c.redo))
+	}
+
+	for len(c.results) > n {
+		if err := c.Undo(); err != nil {
+			return err
+		}
+	}
+
+	for len(c.results) < n {
+		if _, err := c.Redo(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// First undoes every move, returning to the starting position.
+func (c *AlgebraicGameClient) First() error {
+	return c.GoToPly(0)
+}
+
+// Last redoes every undone move, returning to the latest position.
+func (c *AlgebraicGameClient) Last() error {
+	return c.GoToPly(len(c.results) + len(c.redo))
+}
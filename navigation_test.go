@@ -0,0 +1,184 @@
+package chess
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClientUndoRedo(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+
+	if err := client.Undo(); err == nil {
+		t.Fatalf("expected Undo to fail without moves")
+	}
+	if _, err := client.Redo(); err == nil {
+		t.Fatalf("expected Redo to fail without undone moves")
+	}
+
+	undos := 0
+	client.On("undo", func(interface{}) {
+		undos++
+	})
+
+	fens := []string{client.FEN()}
+	for _, mv := range []string{"e4", "e5", "Nf3"} {
+		mustMove(t, client, mv)
+		fens = append(fens, client.FEN())
+	}
+
+	for i := 2; i >= 0; i-- {
+		if err := client.Undo(); err != nil {
+			t.Fatalf("Undo() error = %v", err)
+		}
+		if got := client.FEN(); got != fens[i] {
+			t.Fatalf("FEN after undo:\n got: %s\nwant: %s", got, fens[i])
+		}
+	}
+
+	if undos != 3 {
+		t.Fatalf("expected 3 undo events, got %d", undos)
+	}
+	if got := len(mustStatus(t, client, false).NotatedMoves); got != 20 {
+		t.Fatalf("expected 20 notated moves at the start, got %d", got)
+	}
+
+	for i := 1; i <= 3; i++ {
+		res, err := client.Redo()
+		if err != nil {
+			t.Fatalf("Redo() error = %v", err)
+		}
+		if got := client.FEN(); got != fens[i] {
+			t.Fatalf("FEN after redo of %s:\n got: %s\nwant: %s", res.Move.Algebraic, got, fens[i])
+		}
+	}
+
+	if _, err := client.Redo(); err == nil {
+		t.Fatalf("expected Redo to fail at the latest position")
+	}
+}
+
+func TestClientGoToPly(t *testing.T) {
+	pg, err := ParsePGN(operaGamePGN)
+	if err != nil {
+		t.Fatalf("ParsePGN() error = %v", err)
+	}
+
+	client := pg.Client
+	last := client.FEN()
+
+	if err := client.GoToPly(24); err != nil {
+		t.Fatalf("GoToPly() error = %v", err)
+	}
+	if client.Ply() != 24 {
+		t.Fatalf("expected ply 24, got %d", client.Ply())
+	}
+	if got := client.FEN(); got != "3rkb1r/p2nqppp/5n2/1B2p1B1/4P3/1Q6/PPP2PPP/2KR3R w k - 3 13" {
+		t.Fatalf("unexpected FEN at ply 24: %s", got)
+	}
+
+	if err := client.First(); err != nil {
+		t.Fatalf("First() error = %v", err)
+	}
+	if got := client.FEN(); got != "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1" {
+		t.Fatalf("unexpected FEN at the first ply: %s", got)
+	}
+
+	status := mustStatus(t, client, false)
+	if status.IsCheckmate || status.Result != ResultOngoing {
+		t.Fatalf("expected status to be recalculated at the first ply")
+	}
+
+	if err := client.Last(); err != nil {
+		t.Fatalf("Last() error = %v", err)
+	}
+	if got := client.FEN(); got != last {
+		t.Fatalf("FEN at the last ply:\n got: %s\nwant: %s", got, last)
+	}
+	if !mustStatus(t, client, false).IsCheckmate {
+		t.Fatalf("expected checkmate at the last ply")
+	}
+
+	for _, n := range []int{-1, 34} {
+		if err := client.GoToPly(n); err == nil {
+			t.Fatalf("expected GoToPly(%d) to fail", n)
+		}
+	}
+}
+
+func TestMoveAfterUndoDiscardsRedo(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+
+	for _, mv := range []string{"e4", "e5", "Nf3", "Nc6"} {
+		mustMove(t, client, mv)
+	}
+
+	if err := client.GoToPly(2); err != nil {
+		t.Fatalf("GoToPly() error = %v", err)
+	}
+
+	// replaying the next move keeps the remainder of the line
+	mustMove(t, client, "Nf3")
+	if err := client.Last(); err != nil {
+		t.Fatalf("Last() error = %v", err)
+	}
+	if client.Ply() != 4 {
+		t.Fatalf("expected ply 4, got %d", client.Ply())
+	}
+
+	if err := client.GoToPly(2); err != nil {
+		t.Fatalf("GoToPly() error = %v", err)
+	}
+
+	mustMove(t, client, "Bc4")
+	if _, err := client.Redo(); err == nil {
+		t.Fatalf("expected a new move to discard the undone moves")
+	}
+}
+
+func TestMoveResultUndoCanBeRedone(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+
+	mustMove(t, client, "e4")
+	mustMove(t, client, "c5").Undo()
+
+	res, err := client.Redo()
+	if err != nil {
+		t.Fatalf("Redo() error = %v", err)
+	}
+	if res.Move.Algebraic != "c5" || client.Ply() != 2 {
+		t.Fatalf("expected c5 to be redone, got %s", res.Move.Algebraic)
+	}
+}
+
+func TestNavigationKeepsRecordedResult(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+
+	for _, mv := range []string{"e4", "e5", "Nf3"} {
+		mustMove(t, client, mv)
+	}
+
+	if err := client.Resign(Black); err != nil {
+		t.Fatalf("Resign() error = %v", err)
+	}
+
+	if err := client.First(); err != nil {
+		t.Fatalf("First() error = %v", err)
+	}
+
+	if status := mustStatus(t, client, false); status.Result != ResultOngoing {
+		t.Fatalf("expected the game to be open before the resignation, got %s", status.Result)
+	}
+
+	if err := client.Last(); err != nil {
+		t.Fatalf("Last() error = %v", err)
+	}
+
+	status := mustStatus(t, client, false)
+	if status.Result != ResultWhiteWins || status.Termination != TerminationResignation {
+		t.Fatalf("expected 1-0 by resignation, got %s (%s)", status.Result, status.Termination)
+	}
+
+	if _, err := client.Move("Nc6"); !errors.Is(err, ErrGameOver) {
+		t.Fatalf("expected ErrGameOver, got %v", err)
+	}
+}
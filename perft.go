@@ -0,0 +1,53 @@
+package chess
+
+// Perft counts the leaf nodes of the tree of legal moves of the given depth
+// below the position, making and unmaking each move in place.
+func (p *Position) Perft(depth int) uint64 {
+	if depth <= 0 {
+		return 1
+	}
+
+	var buf [256]Move
+	mvs := p.LegalMoves(buf[:0])
+	if depth == 1 {
+		return uint64(len(mvs))
+	}
+
+	var nodes uint64
+	for _, m := range mvs {
+		st := p.MakeMove(m)
+		nodes += p.Perft(depth - 1)
+		p.UnmakeMove(st)
+	}
+
+	return nodes
+}
+
+// Perft counts the positions reached by every sequence of legal moves of the
+// given depth (in plies) from the current position. Comparing the counts with
+// published reference values verifies the move generator, including castling,
+// en passant and promotion rules. A depth of zero counts the current position.
+func (c *AlgebraicGameClient) Perft(depth int) uint64 {
+	return newPosition(c.game).Perft(depth)
+}
+
+// PerftDivide performs Perft for each legal move of the current position,
+// returning the number of positions below each move keyed by its UCI long
+// algebraic notation (e.g. "e2e4"). The counts help to find the move whose
+// subtree differs from a reference engine.
+func (c *AlgebraicGameClient) PerftDivide(depth int) map[string]uint64 {
+	div := map[string]uint64{}
+	if depth <= 0 {
+		return div
+	}
+
+	pos := newPosition(c.game)
+	for _, m := range pos.LegalMoves(nil) {
+		uci := pos.UCI(m)
+		st := pos.MakeMove(m)
+		div[uci] = pos.Perft(depth - 1)
+		pos.UnmakeMove(st)
+	}
+
+	return div
+}
@@ -0,0 +1,99 @@
+package chess
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+// perftSuite contains reference node counts, keyed by depth, for the standard
+// perft positions and for positions exercising the corner cases of en
+// passant, castling and promotion.
+var perftSuite = []struct {
+	name  string
+	fen   string
+	c960  bool
+	nodes map[int]uint64
+}{
+	{"initial", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", false, map[int]uint64{0: 1, 1: 20, 2: 400, 3: 8902, 4: 197281, 5: 4865609}},
+	{"kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", false, map[int]uint64{1: 48, 2: 2039, 3: 97862, 4: 4085603}},
+	{"position 3", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", false, map[int]uint64{1: 14, 2: 191, 3: 2812, 4: 43238, 5: 674624}},
+	{"position 4", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", false, map[int]uint64{1: 6, 2: 264, 3: 9467, 4: 422333}},
+	{"position 4 mirrored", "r2q1rk1/pP1p2pp/Q4n2/bbp1p3/Np6/1B3NBn/pPPP1PPP/R3K2R b KQ - 0 1", false, map[int]uint64{1: 6, 2: 264, 3: 9467, 4: 422333}},
+	{"position 5", "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", false, map[int]uint64{1: 44, 2: 1486, 3: 62379, 4: 2103487}},
+	{"position 6", "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10", false, map[int]uint64{1: 46, 2: 2079, 3: 89890, 4: 3894594}},
+	{"illegal en passant (pinned)", "3k4/3p4/8/K1P4r/8/8/8/8 b - - 0 1", false, map[int]uint64{6: 1134888}},
+	{"illegal en passant (discovered)", "8/8/4k3/8/2p5/8/B2P2K1/8 w - - 0 1", false, map[int]uint64{6: 1015133}},
+	{"en passant gives check", "8/8/1k6/2b5/2pP4/8/5K2/8 b - d3 0 1", false, map[int]uint64{6: 1440467}},
+	{"short castling gives check", "5k2/8/8/8/8/8/8/4K2R w K - 0 1", false, map[int]uint64{6: 661072}},
+	{"long castling gives check", "3k4/8/8/8/8/8/8/R3K3 w Q - 0 1", false, map[int]uint64{6: 803711}},
+	{"castling rights", "r3k2r/1b4bq/8/8/8/8/7B/R3K2R w KQkq - 0 1", false, map[int]uint64{4: 1274206}},
+	{"castling prevented", "r3k2r/8/3Q4/8/8/5q2/8/R3K2R b KQkq - 0 1", false, map[int]uint64{4: 1720476}},
+	{"promote out of check", "2K2r2/4P3/8/8/8/8/8/3k4 w - - 0 1", false, map[int]uint64{6: 3821001}},
+	{"discovered check", "8/8/1P2K3/8/2n5/1q6/8/5k2 b - - 0 1", false, map[int]uint64{5: 1004658}},
+	{"promote to give check", "4k3/1P6/8/8/8/8/K7/8 w - - 0 1", false, map[int]uint64{6: 217342}},
+	{"underpromote to give check", "8/P1k5/K7/8/8/8/8/8 w - - 0 1", false, map[int]uint64{6: 92683}},
+	{"self stalemate", "K1k5/8/P7/8/8/8/8/8 w - - 0 1", false, map[int]uint64{6: 2217}},
+	{"stalemate and checkmate", "8/k1P5/8/1K6/8/8/8/8 w - - 0 1", false, map[int]uint64{7: 567584}},
+	{"stalemate and checkmate 2", "8/8/2k5/5q2/5n2/8/5K2/8 b - - 0 1", false, map[int]uint64{4: 23527}},
+	{"chess960", "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9", true, map[int]uint64{1: 21, 2: 528, 3: 12189, 4: 326672}},
+}
+
+func TestPerft(t *testing.T) {
+	for _, tc := range perftSuite {
+		t.Run(tc.name, func(t *testing.T) {
+			client := mustFEN(t, tc.fen, AlgebraicClientOptions{Chess960: tc.c960})
+
+			for _, depth := range slices.Sorted(maps.Keys(tc.nodes)) {
+				want := tc.nodes[depth]
+
+				// the deepest searches take a while
+				if testing.Short() && want > 1000000 {
+					break
+				}
+
+				if got := client.Perft(depth); got != want {
+					t.Fatalf("perft(%d): expected %d nodes, got %d", depth, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestPerftDivide(t *testing.T) {
+	client := mustFEN(t, perftSuite[1].fen)
+
+	div := client.PerftDivide(2)
+	if got := slices.Sorted(maps.Keys(div)); !slices.Equal(got, client.UCIMoves()) {
+		t.Fatalf("expected a count for every legal move, got %v", got)
+	}
+
+	var total uint64
+	for _, n := range div {
+		total += n
+	}
+
+	if total != 2039 {
+		t.Fatalf("expected the counts to total 2039, got %d", total)
+	}
+
+	// castling leaves black 43 replies, while a2a4 allows the b4 pawn to capture en passant
+	if div["e1g1"] != 43 || div["a2a4"] != 44 {
+		t.Fatalf("expected e1g1: 43 and a2a4: 44, got %d and %d", div["e1g1"], div["a2a4"])
+	}
+
+	if got := client.PerftDivide(0); len(got) != 0 {
+		t.Fatalf("expected no moves at depth 0, got %v", got)
+	}
+}
+
+func BenchmarkPerft(b *testing.B) {
+	client, err := CreateAlgebraicGameClientFromFEN(perftSuite[1].fen)
+	if err != nil {
+		b.Fatalf("failed to load FEN: %v", err)
+	}
+
+	for b.Loop() {
+		client.Perft(3)
+	}
+}
@@ -0,0 +1,618 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// pgnTokenType is an enumeration of the lexical tokens found in PGN text.
+type pgnTokenType int
+
+const (
+	pgnTokenSymbol   pgnTokenType = iota // A move, move number, result or tag name.
+	pgnTokenString                       // A quoted string (tag values).
+	pgnTokenPeriod                       // A period following a move number.
+	pgnTokenAsterisk                     // The "*" result for unfinished games.
+	pgnTokenTagOpen                      // The "[" opening a tag pair.
+	pgnTokenTagClose                     // The "]" closing a tag pair.
+	pgnTokenRAVOpen                      // The "(" opening a variation.
+	pgnTokenRAVClose                     // The ")" closing a variation.
+	pgnTokenNAG                          // A numeric annotation glyph ($1) or suffix (!?).
+	pgnTokenComment                      // A brace or rest-of-line comment.
+)
+
+// pgnToken is a single lexical token of PGN text.
+type pgnToken struct {
+	typ pgnTokenType
+	val string
+}
+
+// pgnResults contains the game termination markers allowed in movetext.
+var pgnResults = []string{"1-0", "0-1", "1/2-1/2", "*"}
+
+// PGNGame represents a single game loaded from Portable Game Notation (PGN).
+type PGNGame struct {
+	// Client is a game client with every move of the main line applied.
+	Client *AlgebraicGameClient
+	// Moves is the main line, in the order the moves appear in the movetext.
+	Moves []string
+	// Result is the game termination marker ("1-0", "0-1", "1/2-1/2" or "*").
+	Result string
+	// Tags contains every tag pair of the game, keyed by tag name (e.g. "White").
+	Tags map[string]string
+	// Tree contains the main line along with every variation and annotation.
+	Tree *GameTree
+}
+
+// PGNError describes a movetext token that could not be applied to a game.
+type PGNError struct {
+	Ply   int    // Ply is the 1-based half-move number of the failing token.
+	Token string // Token is the movetext exactly as it appeared in the PGN.
+	Err   error  // Err is the underlying failure.
+}
+
+func (e *PGNError) Error() string {
+	return fmt.Sprintf("pgn: ply %d (%s): %v", e.Ply, e.Token, e.Err)
+}
+
+func (e *PGNError) Unwrap() error {
+	return e.Err
+}
+
+// lexPGN breaks PGN text into tokens. Escaped lines (beginning with "%") are
+// discarded, as are characters that have no meaning in PGN.
+func lexPGN(pgn string) ([]pgnToken, error) {
+	tkns := []pgnToken{}
+	lineStart := true
+
+	for i := 0; i < len(pgn); {
+		ch := pgn[i]
+
+		// escape mechanism: lines beginning with % are ignored
+		if lineStart && ch == '%' {
+			for i < len(pgn) && pgn[i] != '\n' {
+				i++
+			}
+			continue
+		}
+		lineStart = ch == '\n'
+
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\r' || ch == '\n':
+			i++
+		case ch == '[':
+			tkns = append(tkns, pgnToken{typ: pgnTokenTagOpen, val: "["})
+			i++
+		case ch == ']':
+			tkns = append(tkns, pgnToken{typ: pgnTokenTagClose, val: "]"})
+			i++
+		case ch == '(':
+			tkns = append(tkns, pgnToken{typ: pgnTokenRAVOpen, val: "("})
+			i++
+		case ch == ')':
+			tkns = append(tkns, pgnToken{typ: pgnTokenRAVClose, val: ")"})
+			i++
+		case ch == '.':
+			tkns = append(tkns, pgnToken{typ: pgnTokenPeriod, val: "."})
+			i++
+		case ch == '*':
+			tkns = append(tkns, pgnToken{typ: pgnTokenAsterisk, val: "*"})
+			i++
+		case ch == '"':
+			var b strings.Builder
+			i++
+			for i < len(pgn) && pgn[i] != '"' {
+				if pgn[i] == '\\' && i+1 < len(pgn) {
+					i++
+				}
+				b.WriteByte(pgn[i])
+				i++
+			}
+			if i >= len(pgn) {
+				return nil, errors.New("pgn: unterminated string")
+			}
+			i++
+			tkns = append(tkns, pgnToken{typ: pgnTokenString, val: b.String()})
+		case ch == '{':
+			end := strings.IndexByte(pgn[i:], '}')
+			if end < 0 {
+				return nil, errors.New("pgn: unterminated comment")
+			}
+			tkns = append(tkns, pgnToken{typ: pgnTokenComment, val: strings.TrimSpace(pgn[i+1 : i+end])})
+			i += end + 1
+		case ch == ';':
+			end := strings.IndexByte(pgn[i:], '\n')
+			if end < 0 {
+				end = len(pgn) - i
+			}
+			tkns = append(tkns, pgnToken{typ: pgnTokenComment, val: strings.TrimSpace(pgn[i+1 : i+end])})
+			i += end
+		case ch == '$':
+			j := i + 1
+			for j < len(pgn) && pgn[j] >= '0' && pgn[j] <= '9' {
+				j++
+			}
+			tkns = append(tkns, pgnToken{typ: pgnTokenNAG, val: pgn[i:j]})
+			i = j
+		case ch == '!' || ch == '?':
+			j := i + 1
+			for j < len(pgn) && (pgn[j] == '!' || pgn[j] == '?') {
+				j++
+			}
+			tkns = append(tkns, pgnToken{typ: pgnTokenNAG, val: pgn[i:j]})
+			i = j
+		case isPGNSymbolStart(ch):
+			j := i + 1
+			for j < len(pgn) && isPGNSymbolContinuation(pgn[j]) {
+				j++
+			}
+			tkns = append(tkns, pgnToken{typ: pgnTokenSymbol, val: pgn[i:j]})
+			i = j
+		default:
+			// characters without meaning in PGN are skipped
+			i++
+		}
+	}
+
+	return tkns, nil
+}
+
+func isPGNSymbolStart(ch byte) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
+
+func isPGNSymbolContinuation(ch byte) bool {
+	return isPGNSymbolStart(ch) || strings.IndexByte("_+#=:-/", ch) >= 0
+}
+
+// isPGNMoveNumber reports whether a symbol token is a move number indication.
+func isPGNMoveNumber(sym string) bool {
+	for _, ch := range sym {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+
+	return sym != ""
+}
+
+// isPGNResult reports whether the token is a game termination marker.
+func isPGNResult(tkn pgnToken) bool {
+	if tkn.typ == pgnTokenAsterisk {
+		return true
+	}
+
+	if tkn.typ != pgnTokenSymbol {
+		return false
+	}
+
+	for _, r := range pgnResults {
+		if tkn.val == r {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parsePGNTags consumes the tag pair section at the start of the tokens and
+// returns the tags along with the index of the first movetext token.
+func parsePGNTags(tkns []pgnToken) (map[string]string, int, error) {
+	tags := map[string]string{}
+	i := 0
+
+	for i < len(tkns) && tkns[i].typ == pgnTokenTagOpen {
+		if i+3 >= len(tkns) ||
+			tkns[i+1].typ != pgnTokenSymbol ||
+			tkns[i+2].typ != pgnTokenString ||
+			tkns[i+3].typ != pgnTokenTagClose {
+			return nil, i, errors.New("pgn: malformed tag pair")
+		}
+
+		tags[tkns[i+1].val] = tkns[i+2].val
+		i += 4
+	}
+
+	return tags, i, nil
+}
+
+// ParsePGN loads the first game found in the provided PGN text. The tag pairs
+// are returned as metadata and the movetext is replayed into a GameTree,
+// starting from the position described by the FEN tag when one is present.
+// Variations become alternative moves in the tree, while comments, annotation
+// glyphs and embedded commands (e.g. [%clk 0:03:00]) are attached to the
+// moves they follow. The client is left at the end of the main line. When a
+// move cannot be applied, the returned error is a *PGNError identifying the
+// ply and token that failed.
+func ParsePGN(pgn string, opts ...AlgebraicClientOptions) (*PGNGame, error) {
+	tkns, err := lexPGN(pgn)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, i, err := parsePGNTags(tkns)
+	if err != nil {
+		return nil, err
+	}
+
+	// games of Chess960 are identified by the Variant tag
+	if v := strings.ToLower(tags["Variant"]); strings.Contains(v, "960") || strings.Contains(v, "fischer") {
+		var o AlgebraicClientOptions
+		if len(opts) > 0 {
+			o = opts[0]
+		}
+		o.Chess960 = true
+		opts = []AlgebraicClientOptions{o}
+	}
+
+	var client *AlgebraicGameClient
+	if fen, ok := tags["FEN"]; ok && tags["SetUp"] != "0" {
+		client, err = CreateAlgebraicGameClientFromFEN(fen, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("pgn: invalid FEN tag: %w", err)
+		}
+	} else {
+		client = CreateAlgebraicGameClient(opts...)
+	}
+
+	tree, err := CreateGameTree(client)
+	if err != nil {
+		return nil, err
+	}
+
+	pg := &PGNGame{
+		Client: client,
+		Moves:  []string{},
+		Result: "*",
+		Tags:   tags,
+		Tree:   tree,
+	}
+
+	if r, ok := tags["Result"]; ok {
+		pg.Result = r
+	}
+
+	prs := &pgnParser{pg: pg, tkns: tkns}
+	if _, err := prs.line(i, true); err != nil {
+		return nil, err
+	}
+
+	// record results that were not reached on the board, such as resignations
+	if res := resultFromPGN(pg.Result); res != ResultOngoing && client.game.res == ResultOngoing {
+		term := TerminationUnknown
+		if strings.EqualFold(tags["Termination"], "time forfeit") {
+			term = TerminationTimeout
+		}
+		_ = client.end(res, term)
+
+		if tree.current.Status, err = client.Status(); err != nil {
+			return nil, err
+		}
+	}
+
+	return pg, nil
+}
+
+// pgnParser replays PGN movetext into the game tree of a PGNGame.
+type pgnParser struct {
+	pg   *PGNGame
+	tkns []pgnToken
+}
+
+// line replays the movetext beginning at tkns[i] from the tree's current
+// node, until the end of the variation or, for the main line, the game
+// termination marker or the tag section of the next game. It returns the
+// index of the token that ended the line.
+func (p *pgnParser) line(i int, main bool) (int, error) {
+	tree := p.pg.Tree
+
+	// the last move of this line, and comments preceding the line's first move
+	var last *GameNode
+	var pending []string
+
+	for ; i < len(p.tkns); i++ {
+		tkn := p.tkns[i]
+
+		switch {
+		case tkn.typ == pgnTokenTagOpen && main:
+			// a new tag section marks the beginning of the next game
+			return i, nil
+		case tkn.typ == pgnTokenRAVOpen:
+			// a variation replaces the last move played
+			if last == nil {
+				return i, errors.New("pgn: variation without a preceding move")
+			}
+
+			if err := tree.GoTo(last.Parent); err != nil {
+				return i, err
+			}
+
+			j, err := p.line(i+1, false)
+			if err != nil {
+				return j, err
+			}
+
+			if err := tree.GoTo(last); err != nil {
+				return j, err
+			}
+			i = j
+		case tkn.typ == pgnTokenRAVClose:
+			if main {
+				return i, errors.New("pgn: unbalanced variation")
+			}
+			return i, nil
+		case tkn.typ == pgnTokenComment:
+			switch {
+			case last != nil:
+				last.Annotation.addComment(tkn.val)
+			case main:
+				tree.Root().Annotation.addComment(tkn.val)
+			default:
+				pending = append(pending, tkn.val)
+			}
+		case tkn.typ == pgnTokenNAG:
+			if last != nil {
+				last.Annotation.addNAG(tkn.val)
+			}
+		case isPGNResult(tkn):
+			// results within variations are ignored
+			if main {
+				p.pg.Result = tkn.val
+				return i, nil
+			}
+		case tkn.typ == pgnTokenSymbol && !isPGNMoveNumber(tkn.val):
+			n, err := tree.Move(tkn.val)
+			if err != nil {
+				return i, &PGNError{Ply: tree.Current().Ply + 1, Token: tkn.val, Err: err}
+			}
+
+			if main {
+				p.pg.Moves = append(p.pg.Moves, tkn.val)
+			}
+
+			n.Annotation.StartingComments = append(n.Annotation.StartingComments, pending...)
+			pending = nil
+			last = n
+		}
+	}
+
+	if !main {
+		return i, errors.New("pgn: unterminated variation")
+	}
+
+	return i, nil
+}
+
+// pgnSevenTagRoster lists the tags required, in order, by the PGN export format.
+var pgnSevenTagRoster = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+// pgnLineLength is the maximum length of a movetext line in exported PGN.
+const pgnLineLength = 79
+
+// escapePGNString escapes the quote and backslash characters of a tag value.
+func escapePGNString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, `"`, `\"`)
+}
+
+// PGN returns the game in the Portable Game Notation export format. Any tags
+// provided are included alongside the Seven Tag Roster (missing roster tags
+// are given their "unknown" values), and games that began from a custom
+// position include the SetUp and FEN tags. When no Result tag is provided,
+// the result of the game is used. Annotations attached to the moves are
+// written as comments and NAGs.
+func (c *AlgebraicGameClient) PGN(tags ...map[string]string) string {
+	// chain the moves played into a single line
+	root := &GameNode{}
+	n := root
+	for _, mv := range c.game.MoveHistory {
+		nxt := &GameNode{Annotation: mv.Annotation, Move: mv.Algebraic, Parent: n}
+		n.Children = []*GameNode{nxt}
+		n = nxt
+	}
+
+	return writePGN(c.fen, c.fen, c.game.c960, c.game.res, root, tags...)
+}
+
+// PGN returns the game tree in the Portable Game Notation export format,
+// including every variation and annotation. Tags are handled as for
+// AlgebraicGameClient.PGN, and the result is taken from the end of the main
+// line unless a Result tag is provided.
+func (t *GameTree) PGN(tags ...map[string]string) string {
+	setup := t.root.FEN
+	if t.base == 0 {
+		setup = t.client.fen
+	}
+
+	res := t.root.Status.Result
+	if line := t.MainLine(); len(line) > 0 {
+		res = line[len(line)-1].Status.Result
+	}
+
+	return writePGN(setup, t.root.FEN, t.client.game.c960, res, t.root, tags...)
+}
+
+// PGN returns the game in the Portable Game Notation export format, using
+// the tag pairs, variations and annotations that were loaded with it.
+func (pg *PGNGame) PGN() string {
+	return pg.Tree.PGN(pg.Tags)
+}
+
+// writePGN writes the tag pair section followed by the movetext of the moves
+// following root. The SetUp and FEN tags are written when setup is not empty,
+// and move numbers follow the side to move and fullmove number of fen.
+func writePGN(setup, fen string, c960 bool, res Result, root *GameNode, tags ...map[string]string) string {
+	tg := map[string]string{
+		"Event":  "?",
+		"Site":   "?",
+		"Date":   "????.??.??",
+		"Round":  "?",
+		"White":  "?",
+		"Black":  "?",
+		"Result": res.String(),
+	}
+
+	for _, t := range tags {
+		for k, v := range t {
+			tg[k] = v
+		}
+	}
+
+	if setup != "" {
+		tg["SetUp"] = "1"
+		tg["FEN"] = strings.Join(strings.Fields(setup), " ")
+	}
+
+	if c960 {
+		tg["Variant"] = "Chess960"
+	}
+
+	// numbering begins from the starting position's fullmove number
+	fmn, white := 1, true
+	if parts := strings.Fields(fen); len(parts) > 5 {
+		if n, err := strconv.Atoi(parts[5]); err == nil && n > 0 {
+			fmn = n
+		}
+		white = parts[1] != "b"
+	}
+
+	var b strings.Builder
+
+	// tag pair section: the roster in order followed by remaining tags in ASCII order
+	other := []string{}
+	for k := range tg {
+		if !slices.Contains(pgnSevenTagRoster, k) {
+			other = append(other, k)
+		}
+	}
+	slices.Sort(other)
+
+	for _, k := range append(slices.Clone(pgnSevenTagRoster), other...) {
+		fmt.Fprintf(&b, "[%s \"%s\"]\n", k, escapePGNString(tg[k]))
+	}
+	b.WriteRune('\n')
+
+	// movetext section
+	tkns := []string{}
+	if root.Annotation != nil {
+		for _, cmt := range root.Annotation.Comments {
+			tkns = appendPGNComment(tkns, cmt)
+		}
+	}
+	tkns = appendPGNLine(tkns, root, fmn, white, true)
+	tkns = append(tkns, tg["Result"])
+
+	ln := 0
+	for i, tkn := range tkns {
+		// variations are written without spaces inside the parentheses
+		if i > 0 && tkns[i-1] != "(" && tkn != ")" {
+			if ln+1+len(tkn) > pgnLineLength {
+				b.WriteRune('\n')
+				ln = 0
+			} else {
+				b.WriteRune(' ')
+				ln++
+			}
+		}
+
+		b.WriteString(tkn)
+		ln += len(tkn)
+	}
+	b.WriteRune('\n')
+
+	return b.String()
+}
+
+// appendPGNLine appends the movetext of the main line following n, with the
+// variations of each move, to tkns. The move number is written before
+// white's moves, and before black's when number is true (e.g. at the start of
+// a line or after a comment or variation).
+func appendPGNLine(tkns []string, n *GameNode, fmn int, white, number bool) []string {
+	for len(n.Children) > 0 {
+		main := n.Children[0]
+		tkns = appendPGNMove(tkns, main, fmn, white, number)
+		number = main.Annotation.hasComments()
+
+		for _, v := range n.Children[1:] {
+			tkns = append(tkns, "(")
+			tkns = appendPGNMove(tkns, v, fmn, white, true)
+
+			nf := fmn
+			if !white {
+				nf++
+			}
+			tkns = appendPGNLine(tkns, v, nf, !white, v.Annotation.hasComments())
+			tkns = append(tkns, ")")
+			number = true
+		}
+
+		if !white {
+			fmn++
+		}
+		white = !white
+		n = main
+	}
+
+	return tkns
+}
+
+// appendPGNMove appends a single move, preceded by its starting comments and
+// move number and followed by its NAGs and comments, to tkns.
+func appendPGNMove(tkns []string, n *GameNode, fmn int, white, number bool) []string {
+	a := n.Annotation
+	if a != nil {
+		for _, cmt := range a.StartingComments {
+			tkns = appendPGNComment(tkns, cmt)
+		}
+	}
+
+	if white {
+		tkns = append(tkns, fmt.Sprintf("%d.", fmn))
+	} else if number || (a != nil && len(a.StartingComments) > 0) {
+		tkns = append(tkns, fmt.Sprintf("%d...", fmn))
+	}
+
+	tkns = append(tkns, n.Move)
+
+	if a == nil {
+		return tkns
+	}
+
+	for _, nag := range a.NAGs {
+		tkns = append(tkns, fmt.Sprintf("$%d", nag))
+	}
+
+	// embedded commands are written at the start of the first comment
+	cmts := slices.Clone(a.Comments)
+	if cmd := a.commandText(); cmd != "" {
+		if len(cmts) == 0 {
+			cmts = []string{cmd}
+		} else {
+			cmts[0] = cmd + " " + cmts[0]
+		}
+	}
+
+	for _, cmt := range cmts {
+		tkns = appendPGNComment(tkns, cmt)
+	}
+
+	return tkns
+}
+
+// appendPGNComment appends a brace comment to tkns, one word per token so
+// that long comments can be wrapped across lines.
+func appendPGNComment(tkns []string, cmt string) []string {
+	// a closing brace would end the comment early
+	words := strings.Fields(strings.ReplaceAll(cmt, "}", ")"))
+	if len(words) == 0 {
+		return tkns
+	}
+
+	words[0] = "{" + words[0]
+	words[len(words)-1] += "}"
+
+	return append(tkns, words...)
+}
@@ -0,0 +1,342 @@
+package chess
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// defaultPGNMaxGameSize is the default limit, in bytes, of a single game's text.
+const defaultPGNMaxGameSize = 1 << 20
+
+// ErrPGNGameTooLarge is reported for games whose text exceeds the maximum game size.
+var ErrPGNGameTooLarge = errors.New("pgn: game exceeds the maximum size")
+
+// PGNReaderOptions configures how a PGN database is read.
+type PGNReaderOptions struct {
+	ClientOptions AlgebraicClientOptions // ClientOptions are applied to the client created for each game.
+	MaxGameSize   int                    // MaxGameSize limits the size of a single game's text in bytes (1 MiB when zero).
+	Workers       int                    // Workers is the number of games parsed and validated in parallel (1 when zero).
+}
+
+// PGNReadError describes a game of a PGN database that could not be read.
+type PGNReadError struct {
+	Game   int   // Game is the 0-based index of the game within the database.
+	Offset int64 // Offset is the byte offset at which the game's text begins.
+	Err    error // Err is the underlying failure, such as a *PGNError.
+}
+
+func (e *PGNReadError) Error() string {
+	return fmt.Sprintf("pgn: game %d (offset %d): %v", e.Game, e.Offset, e.Err)
+}
+
+func (e *PGNReadError) Unwrap() error {
+	return e.Err
+}
+
+// pgnReader streams the games of a PGN database.
+type pgnReader struct {
+	opts PGNReaderOptions
+	r    io.Reader
+}
+
+// pgnChunk is the text of a single game within a PGN database.
+type pgnChunk struct {
+	err    error
+	idx    int
+	offset int64
+	txt    []byte
+}
+
+// pgnReadResult is the outcome of parsing a single chunk.
+type pgnReadResult struct {
+	err error
+	pg  *PGNGame
+}
+
+// CreatePGNReader creates a reader for a PGN database containing any number
+// of games. Games are read from r as they are iterated, so that only the
+// games being parsed are held in memory.
+func CreatePGNReader(r io.Reader, opts ...PGNReaderOptions) *pgnReader {
+	var o PGNReaderOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	if o.MaxGameSize <= 0 {
+		o.MaxGameSize = defaultPGNMaxGameSize
+	}
+
+	if o.Workers <= 0 {
+		o.Workers = 1
+	}
+
+	return &pgnReader{opts: o, r: r}
+}
+
+// All returns an iterator over the games of the database, in the order they
+// appear. A game that cannot be parsed is yielded with a *PGNReadError and
+// reading continues with the next game; a failure to read the underlying
+// stream is yielded as a *PGNReadError and ends the iteration. When more than
+// one worker is configured, games are parsed in parallel (while still being
+// yielded in order) and the reader should only be iterated once.
+func (pr *pgnReader) All() func(yld func(*PGNGame, error) bool) {
+	return func(yld func(*PGNGame, error) bool) {
+		scn := &pgnScanner{br: bufio.NewReader(pr.r), lnStart: true, max: pr.opts.MaxGameSize}
+
+		if pr.opts.Workers == 1 {
+			for {
+				chk, ok := scn.next()
+				if !ok {
+					return
+				}
+
+				res := pr.parse(chk)
+				if !yld(res.pg, res.err) {
+					return
+				}
+			}
+		}
+
+		pr.parallel(scn, yld)
+	}
+}
+
+// pgnJob pairs a game with the channel receiving the result of parsing it.
+type pgnJob struct {
+	chk pgnChunk
+	res chan pgnReadResult
+}
+
+// parallel parses games across the configured number of workers. The queue
+// of pending results preserves the order of the games and bounds the number
+// held in memory.
+func (pr *pgnReader) parallel(scn *pgnScanner, yld func(*PGNGame, error) bool) {
+	done := make(chan struct{})
+	jobs := make(chan pgnJob)
+	queue := make(chan chan pgnReadResult, pr.opts.Workers)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer close(done)
+
+	// read games from the stream...
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(queue)
+		defer close(jobs)
+
+		for {
+			chk, ok := scn.next()
+			if !ok {
+				return
+			}
+
+			job := pgnJob{chk: chk, res: make(chan pgnReadResult, 1)}
+			select {
+			case queue <- job.res:
+			case <-done:
+				return
+			}
+
+			select {
+			case jobs <- job:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	// ...parse them in parallel...
+	for range pr.opts.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				job.res <- pr.parse(job.chk)
+			}
+		}()
+	}
+
+	// ...and yield them in order
+	for res := range queue {
+		r := <-res
+		if !yld(r.pg, r.err) {
+			return
+		}
+	}
+}
+
+// parse parses the text of a single game.
+func (pr *pgnReader) parse(chk pgnChunk) pgnReadResult {
+	if chk.err != nil {
+		return pgnReadResult{err: &PGNReadError{Game: chk.idx, Offset: chk.offset, Err: chk.err}}
+	}
+
+	pg, err := ParsePGN(string(chk.txt), pr.opts.ClientOptions)
+	if err != nil {
+		return pgnReadResult{err: &PGNReadError{Game: chk.idx, Offset: chk.offset, Err: err}}
+	}
+
+	return pgnReadResult{pg: pg}
+}
+
+// pgnLineKind classifies the line of a PGN database being scanned.
+type pgnLineKind int
+
+const (
+	pgnLineMovetext pgnLineKind = iota // A line of movetext (or blank line).
+	pgnLineTag                         // A tag pair line, beginning with "[".
+	pgnLineEscape                      // An escaped line, beginning with "%".
+)
+
+// pgnScanner splits a PGN database into the text of its games. A game ends
+// where a tag section begins after movetext, outside of any comment, so that
+// only a single game (up to the maximum size) is buffered at a time.
+type pgnScanner struct {
+	br     *bufio.Reader
+	done   bool
+	err    error
+	idx    int
+	max    int
+	offset int64
+
+	// the game being scanned
+	cmt      bool
+	content  bool
+	kind     pgnLineKind
+	lnStart  bool
+	movetext bool
+	semi     bool
+	start    int64
+	tooLarge bool
+	txt      []byte
+}
+
+// next returns the next game of the database, or false once the stream has
+// been consumed. A failure to read the stream is returned as a game with an
+// error and ends the database.
+func (s *pgnScanner) next() (pgnChunk, bool) {
+	if s.done {
+		return pgnChunk{}, false
+	}
+
+	for {
+		if s.err == nil {
+			var frag []byte
+			frag, s.err = s.br.ReadSlice('\n')
+			if errors.Is(s.err, bufio.ErrBufferFull) {
+				// lines longer than the buffer are scanned in fragments
+				s.err = nil
+			}
+
+			if len(frag) > 0 {
+				if chk, ok := s.scan(frag); ok {
+					return chk, true
+				}
+			}
+
+			if s.err == nil {
+				continue
+			}
+		}
+
+		s.done = true
+		if !errors.Is(s.err, io.EOF) {
+			// the game being read when the stream failed is incomplete
+			chk := s.chunk()
+			chk.err = s.err
+			return chk, true
+		}
+
+		if !s.content {
+			return pgnChunk{}, false
+		}
+
+		return s.chunk(), true
+	}
+}
+
+// scan adds a fragment of a line to the game being scanned. When the fragment
+// begins the tag section of another game, the scanned game is returned and
+// the fragment begins the next one.
+func (s *pgnScanner) scan(frag []byte) (pgnChunk, bool) {
+	var chk pgnChunk
+	var ok bool
+
+	if s.lnStart {
+		s.semi = false
+		s.kind = pgnLineMovetext
+
+		switch {
+		case s.cmt:
+			// lines within a brace comment are movetext
+		case frag[0] == '[':
+			s.kind = pgnLineTag
+			if s.movetext {
+				chk, ok = s.chunk(), true
+			}
+		case frag[0] == '%':
+			s.kind = pgnLineEscape
+		}
+	}
+
+	if !s.content {
+		s.start = s.offset
+	}
+
+	for _, ch := range frag {
+		if ch == ' ' || ch == '\t' || ch == '\r' || ch == '\n' {
+			continue
+		}
+		s.content = true
+
+		if s.kind != pgnLineMovetext {
+			continue
+		}
+		s.movetext = true
+
+		switch {
+		case s.cmt:
+			s.cmt = ch != '}'
+		case s.semi:
+		case ch == '{':
+			s.cmt = true
+		case ch == ';':
+			s.semi = true
+		}
+	}
+
+	if !s.content {
+		// leading blank lines are not part of the game
+		s.start = s.offset + int64(len(frag))
+	} else if len(s.txt)+len(frag) > s.max {
+		s.tooLarge = true
+		s.txt = nil
+	} else if !s.tooLarge {
+		s.txt = append(s.txt, frag...)
+	}
+
+	s.offset += int64(len(frag))
+	s.lnStart = frag[len(frag)-1] == '\n'
+
+	return chk, ok
+}
+
+// chunk returns the game scanned so far and resets the scanner for the next.
+func (s *pgnScanner) chunk() pgnChunk {
+	chk := pgnChunk{idx: s.idx, offset: s.start, txt: s.txt}
+	if s.tooLarge {
+		chk.err = ErrPGNGameTooLarge
+	}
+
+	s.idx++
+	s.cmt, s.content, s.movetext, s.tooLarge = false, false, false, false
+	s.start = s.offset
+	s.txt = nil
+
+	return chk
+}
@@ -0,0 +1,160 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+const pgnDatabase = `[Event "First"]
+[Result "1-0"]
+
+1. e4 e5 2. Bc4 Nc6 3. Qh5 Nf6 4. Qxf7# 1-0
+
+[Event "Second"]
+[Result "*"]
+
+1. e4 e5 2. Ke3 *
+
+% an escaped line
+[Event "Third"]
+[Result "1/2-1/2"]
+
+1. d4 {a comment spanning lines
+[%clk 0:01:00] that begins with a bracket} d5 1/2-1/2
+`
+
+func readAll(t *testing.T, r io.Reader, opts ...PGNReaderOptions) ([]*PGNGame, []error) {
+	t.Helper()
+
+	var pgs []*PGNGame
+	var errs []error
+	CreatePGNReader(r, opts...).All()(func(pg *PGNGame, err error) bool {
+		pgs = append(pgs, pg)
+		errs = append(errs, err)
+		return true
+	})
+
+	return pgs, errs
+}
+
+func TestPGNReaderAll(t *testing.T) {
+	pgs, errs := readAll(t, strings.NewReader(pgnDatabase))
+	if len(pgs) != 3 {
+		t.Fatalf("expected 3 games, got %d", len(pgs))
+	}
+
+	if errs[0] != nil || pgs[0].Tags["Event"] != "First" || !pgs[0].Client.isCheckmate {
+		t.Fatalf("expected the first game to end in checkmate, got %v", errs[0])
+	}
+
+	var re *PGNReadError
+	if !errors.As(errs[1], &re) {
+		t.Fatalf("expected a *PGNReadError for the second game, got %v", errs[1])
+	}
+	if want := int64(strings.Index(pgnDatabase, `[Event "Second"]`)); re.Game != 1 || re.Offset != want {
+		t.Fatalf("expected game 1 at offset %d, got game %d at offset %d", want, re.Game, re.Offset)
+	}
+
+	var pe *PGNError
+	if !errors.As(errs[1], &pe) || pe.Ply != 3 || pe.Token != "Ke3" {
+		t.Fatalf("expected the illegal move at ply 3, got %v", errs[1])
+	}
+
+	if errs[2] != nil || pgs[2].Tags["Event"] != "Third" || pgs[2].Result != "1/2-1/2" {
+		t.Fatalf("expected the third game to be read after the malformed game, got %v", errs[2])
+	}
+	if clk, ok := pgs[2].Tree.MainLine()[0].Annotation.Clock(); !ok || clk.Seconds() != 60 {
+		t.Fatalf("expected the comment spanning lines to belong to the third game")
+	}
+}
+
+func TestPGNReaderWorkers(t *testing.T) {
+	var b strings.Builder
+	for i := range 40 {
+		fmt.Fprintf(&b, "[Event \"%d\"]\n\n", i)
+		if i%7 == 3 {
+			b.WriteString("1. e4 e4 *\n\n")
+			continue
+		}
+		b.WriteString("1. Nf3 Nf6 2. Ng1 Ng8 *\n\n")
+	}
+
+	want, wantErrs := readAll(t, strings.NewReader(b.String()))
+	got, gotErrs := readAll(t, strings.NewReader(b.String()), PGNReaderOptions{Workers: 4})
+	if len(got) != 40 || len(want) != 40 {
+		t.Fatalf("expected 40 games, got %d and %d", len(want), len(got))
+	}
+
+	for i := range got {
+		if (gotErrs[i] == nil) != (wantErrs[i] == nil) {
+			t.Fatalf("game %d: expected error %v, got %v", i, wantErrs[i], gotErrs[i])
+		}
+
+		var re *PGNReadError
+		if gotErrs[i] != nil && (!errors.As(gotErrs[i], &re) || re.Game != i) {
+			t.Fatalf("game %d: unexpected error %v", i, gotErrs[i])
+		}
+
+		if got[i] != nil && got[i].Tags["Event"] != fmt.Sprint(i) {
+			t.Fatalf("expected game %d to be yielded in order, got %s", i, got[i].Tags["Event"])
+		}
+	}
+
+	t.Run("stops early", func(t *testing.T) {
+		n := 0
+		CreatePGNReader(strings.NewReader(b.String()), PGNReaderOptions{Workers: 4}).All()(func(*PGNGame, error) bool {
+			n++
+			return n < 5
+		})
+
+		if n != 5 {
+			t.Fatalf("expected iteration to stop after 5 games, got %d", n)
+		}
+	})
+}
+
+func TestPGNReaderRecovery(t *testing.T) {
+	t.Run("game too large", func(t *testing.T) {
+		db := "[Event \"Big\"]\n\n{" + strings.Repeat("long ", 2000) + "}\n1. e4 *\n\n[Event \"Small\"]\n\n1. d4 *\n"
+
+		pgs, errs := readAll(t, strings.NewReader(db), PGNReaderOptions{MaxGameSize: 4096})
+		if len(pgs) != 2 || !errors.Is(errs[0], ErrPGNGameTooLarge) {
+			t.Fatalf("expected the first game to be too large, got %v", errs)
+		}
+
+		if errs[1] != nil || pgs[1].Tags["Event"] != "Small" {
+			t.Fatalf("expected the second game to be read, got %v", errs[1])
+		}
+	})
+
+	t.Run("long lines", func(t *testing.T) {
+		db := "[Event \"Long\"]\n\n1. e4 {" + strings.Repeat("x", 10000) + "} e5 *\n"
+
+		pgs, errs := readAll(t, strings.NewReader(db))
+		if len(pgs) != 1 || errs[0] != nil || len(pgs[0].Moves) != 2 {
+			t.Fatalf("expected a single game of 2 moves, got %v", errs)
+		}
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		boom := errors.New("boom")
+
+		for _, w := range []int{1, 3} {
+			r := io.MultiReader(strings.NewReader(pgnDatabase), iotest.ErrReader(boom))
+
+			pgs, errs := readAll(t, r, PGNReaderOptions{Workers: w})
+			if len(pgs) != 3 || !errors.Is(errs[2], boom) {
+				t.Fatalf("expected the read error to end the database, got %v", errs)
+			}
+
+			var re *PGNReadError
+			if want := int64(strings.Index(pgnDatabase, `[Event "Third"]`)); !errors.As(errs[2], &re) || re.Game != 2 || re.Offset != want {
+				t.Fatalf("expected the read error at game 2 (offset %d), got %v", want, errs[2])
+			}
+		}
+	})
+}
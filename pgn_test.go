@@ -0,0 +1,335 @@
+package chess
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const operaGamePGN = `[Event "Paris"]
+[Site "Paris FRA"]
+[Date "1858.??.??"]
+[Round "?"]
+[White "Paul Morphy"]
+[Black "Duke Karl / Count Isouard"]
+[Result "1-0"]
+[ECO "C41"]
+
+1. e4 e5 2. Nf3 d6 3. d4 Bg4 {This is a weak move already.} 4. dxe5 Bxf3
+5. Qxf3 dxe5 6. Bc4 Nf6 7. Qb3 Qe7 8. Nc3 c6 9. Bg5 b5?! (9... Qb4+ 10. Qxb4)
+10. Nxb5 cxb5 11. Bxb5+ Nbd7 12. O-O-O Rd8 13. Rxd7 Rxd7 14. Rd1 Qe6
+15. Bxd7+ Nxd7 16. Qb8+ $1 Nxb8 17. Rd8# 1-0
+`
+
+func TestParsePGN(t *testing.T) {
+	pg, err := ParsePGN(operaGamePGN)
+	if err != nil {
+		t.Fatalf("ParsePGN() error = %v", err)
+	}
+
+	if got := len(pg.Moves); got != 33 {
+		t.Fatalf("expected 33 moves, got %d", got)
+	}
+	if pg.Result != "1-0" {
+		t.Fatalf("expected result 1-0, got %s", pg.Result)
+	}
+	if pg.Tags["White"] != "Paul Morphy" || pg.Tags["ECO"] != "C41" {
+		t.Fatalf("unexpected tags %v", pg.Tags)
+	}
+	if got := len(pg.Tags); got != 8 {
+		t.Fatalf("expected 8 tags, got %d", got)
+	}
+
+	status := mustStatus(t, pg.Client, false)
+	if !status.IsCheckmate {
+		t.Fatalf("expected checkmate true")
+	}
+
+	exp := "1n1Rkb1r/p4ppp/4q3/4p1B1/4P3/8/PPP2PPP/2K5 b k - 1 17"
+	if got := pg.Client.FEN(); got != exp {
+		t.Fatalf("FEN mismatch:\n got: %s\nwant: %s", got, exp)
+	}
+}
+
+func TestParsePGNFromFEN(t *testing.T) {
+	pgn := `[Event "Endgame"]
+[SetUp "1"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 b - - 0 40"]
+
+40... Kd7 41. e4 Kd6 42. Kd2 *`
+
+	pg, err := ParsePGN(pgn)
+	if err != nil {
+		t.Fatalf("ParsePGN() error = %v", err)
+	}
+
+	if pg.Result != "*" {
+		t.Fatalf("expected result *, got %s", pg.Result)
+	}
+
+	exp := "8/8/3k4/8/4P3/8/3K4/8 b - - 2 42"
+	if got := pg.Client.FEN(); got != exp {
+		t.Fatalf("FEN mismatch:\n got: %s\nwant: %s", got, exp)
+	}
+}
+
+func TestParsePGNReportsFailingPly(t *testing.T) {
+	pgn := `[Event "Broken"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bb5 Nf3 *`
+
+	_, err := ParsePGN(pgn)
+	if err == nil {
+		t.Fatalf("expected an error for an illegal move")
+	}
+
+	var pe *PGNError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected *PGNError, got %T", err)
+	}
+	if pe.Ply != 6 || pe.Token != "Nf3" {
+		t.Fatalf("expected ply 6 (Nf3), got ply %d (%s)", pe.Ply, pe.Token)
+	}
+}
+
+func TestParsePGNMalformed(t *testing.T) {
+	for _, pgn := range []string{
+		`[Event "Unterminated]`,
+		`[Event] 1. e4 *`,
+		`1. e4 {never closed`,
+		`1. e4 (1. d4 *`,
+		`1. e4 ) e5 *`,
+		`[FEN "not a fen"] 1. e4 *`,
+	} {
+		if _, err := ParsePGN(pgn); err == nil {
+			t.Fatalf("expected error for %q", pgn)
+		}
+	}
+}
+
+func TestPGNExport(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+
+	for _, mv := range []string{"f3", "e5", "g4", "Qh4"} {
+		mustMove(t, client, mv)
+	}
+
+	exp := `[Event "Casual"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "?"]
+[Black "?"]
+[Result "0-1"]
+[Annotator "Someone \"Quoted\""]
+
+1. f3 e5 2. g4 Qh4# 0-1
+`
+
+	got := client.PGN(map[string]string{"Event": "Casual", "Annotator": `Someone "Quoted"`})
+	if got != exp {
+		t.Fatalf("PGN mismatch:\n got: %s\nwant: %s", got, exp)
+	}
+}
+
+func TestPGNExportRoundTrip(t *testing.T) {
+	pg, err := ParsePGN(operaGamePGN)
+	if err != nil {
+		t.Fatalf("ParsePGN() error = %v", err)
+	}
+
+	out := pg.PGN()
+
+	for _, frag := range []string{"12. O-O-O Rd8", "11. Bxb5+ Nbd7", "17. Rd8# 1-0", "[ECO \"C41\"]"} {
+		if !strings.Contains(out, frag) {
+			t.Fatalf("expected %q in exported PGN:\n%s", frag, out)
+		}
+	}
+
+	for _, ln := range strings.Split(out, "\n") {
+		if len(ln) > 79 {
+			t.Fatalf("line exceeds 79 characters: %q", ln)
+		}
+	}
+
+	rt, err := ParsePGN(out)
+	if err != nil {
+		t.Fatalf("ParsePGN() of exported PGN error = %v", err)
+	}
+	if rt.Client.FEN() != pg.Client.FEN() {
+		t.Fatalf("round trip mismatch:\n got: %s\nwant: %s", rt.Client.FEN(), pg.Client.FEN())
+	}
+}
+
+func TestPGNExportFromFEN(t *testing.T) {
+	fen := "8/P3k3/8/8/8/8/8/4K3 b - - 0 40"
+	client, err := CreateAlgebraicGameClientFromFEN(fen)
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	mustMove(t, client, "Kd7")
+	mustMove(t, client, "a8Q")
+
+	out := client.PGN()
+	if !strings.Contains(out, "[SetUp \"1\"]\n") || !strings.Contains(out, "[FEN \""+fen+"\"]\n") {
+		t.Fatalf("expected SetUp and FEN tags:\n%s", out)
+	}
+	if !strings.HasSuffix(out, "\n40... Kd7 41. a8=Q *\n") {
+		t.Fatalf("unexpected movetext:\n%s", out)
+	}
+}
+
+const annotatedPGN = `[Event "Lesson"]
+
+{White opens with the king's pawn} 1. e4 {[%clk 0:03:00] [%eval 0.3] Best by test}
+(1. d4 $1 {A solid choice} 1... d5 ({Or} 1... Nf6 2. c4)) 1... e5!?
+{[%cal Gg1f3,Rd7d5] [%csl Re5]} 2. Nf3 ; the most natural move
+2... Nc6 *`
+
+func TestParsePGNAnnotations(t *testing.T) {
+	pg, err := ParsePGN(annotatedPGN)
+	if err != nil {
+		t.Fatalf("ParsePGN() error = %v", err)
+	}
+
+	if got := len(pg.Moves); got != 4 {
+		t.Fatalf("expected 4 main line moves, got %d", got)
+	}
+
+	root := pg.Tree.Root()
+	if got := root.Annotation.Comments; len(got) != 1 || got[0] != "White opens with the king's pawn" {
+		t.Fatalf("unexpected game comment %v", got)
+	}
+
+	if len(root.Children) != 2 {
+		t.Fatalf("expected a variation of the first move, got %d moves", len(root.Children))
+	}
+
+	e4, d4 := root.Children[0], root.Children[1]
+	if got := e4.Annotation.Comments; len(got) != 1 || got[0] != "Best by test" {
+		t.Fatalf("unexpected comments %v", got)
+	}
+	if clk, ok := e4.Annotation.Clock(); !ok || clk != 3*time.Minute {
+		t.Fatalf("expected clock of 3 minutes, got %v", clk)
+	}
+	if ev, _, ok := e4.Annotation.Eval(); !ok || ev != 0.3 {
+		t.Fatalf("expected evaluation of 0.3, got %v", ev)
+	}
+
+	if d4.Move != "d4" || len(d4.Annotation.NAGs) != 1 || d4.Annotation.NAGs[0] != 1 {
+		t.Fatalf("expected d4 with NAG 1, got %s %v", d4.Move, d4.Annotation.NAGs)
+	}
+
+	d5 := d4.Children[0]
+	if len(d4.Children) != 2 || d4.Children[1].Move != "Nf6" {
+		t.Fatalf("expected a nested variation of 1... d5")
+	}
+	if got := d4.Children[1].Annotation.StartingComments; len(got) != 1 || got[0] != "Or" {
+		t.Fatalf("unexpected starting comments %v", got)
+	}
+	if d5.Move != "d5" {
+		t.Fatalf("expected 1... d5, got %s", d5.Move)
+	}
+
+	e5 := e4.Children[0]
+	if len(e5.Annotation.NAGs) != 1 || e5.Annotation.NAGs[0] != 5 {
+		t.Fatalf("expected !? as NAG 5, got %v", e5.Annotation.NAGs)
+	}
+	if got := e5.Annotation.Arrows(); len(got) != 2 || got[0] != "Gg1f3" {
+		t.Fatalf("unexpected arrows %v", got)
+	}
+	if got := e5.Annotation.Highlights(); len(got) != 1 || got[0] != "Re5" {
+		t.Fatalf("unexpected highlights %v", got)
+	}
+
+	// annotations are attached to the moves of the game history
+	hist := pg.Client.game.MoveHistory
+	if len(hist) != 4 || hist[0].Annotation != e4.Annotation {
+		t.Fatalf("expected the main line moves to carry their annotations")
+	}
+	if got := hist[2].Annotation.Comments; len(got) != 1 || got[0] != "the most natural move" {
+		t.Fatalf("unexpected rest-of-line comment %v", got)
+	}
+	if got := pg.Client.FEN(); got != "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3" {
+		t.Fatalf("expected the client at the end of the main line, got %s", got)
+	}
+}
+
+func TestPGNExportAnnotations(t *testing.T) {
+	pg, err := ParsePGN(annotatedPGN)
+	if err != nil {
+		t.Fatalf("ParsePGN() error = %v", err)
+	}
+
+	out := pg.PGN()
+	for _, frag := range []string{
+		"{White opens with the king's pawn} 1. e4 {[%clk 0:03:00] [%eval 0.3] Best by",
+		"(1. d4 $1 {A solid choice} 1... d5 ({Or} 1... Nf6 2. c4)) 1... e5 $5",
+		"{[%cal Gg1f3,Rd7d5] [%csl Re5]} 2. Nf3 {the most natural move} 2... Nc6 *",
+	} {
+		if !strings.Contains(strings.Join(strings.Fields(out), " "), frag) {
+			t.Fatalf("expected %q in exported PGN:\n%s", frag, out)
+		}
+	}
+
+	rt, err := ParsePGN(out)
+	if err != nil {
+		t.Fatalf("ParsePGN() of exported PGN error = %v", err)
+	}
+	if rt.PGN() != out {
+		t.Fatalf("round trip mismatch:\n got: %s\nwant: %s", rt.PGN(), out)
+	}
+
+	// the client exports the annotations of its game history
+	if got := pg.Client.PGN(); !strings.Contains(got, "2. Nf3 {the most natural move}") {
+		t.Fatalf("expected client PGN to include annotations:\n%s", got)
+	}
+}
+
+func TestParsePGNIllegalVariation(t *testing.T) {
+	_, err := ParsePGN(`1. e4 e5 (1... e4) *`)
+
+	var pe *PGNError
+	if !errors.As(err, &pe) || pe.Ply != 2 || pe.Token != "e4" {
+		t.Fatalf("expected illegal variation move at ply 2, got %v", err)
+	}
+}
+
+func TestParsePGNNavigateVariationAfterResult(t *testing.T) {
+	pg, err := ParsePGN(`[Result "1-0"]
+
+1. e4 e5 (1... c5 2. Nf3) 2. Nf3 Nc6 1-0`)
+	if err != nil {
+		t.Fatalf("ParsePGN() error = %v", err)
+	}
+
+	e4 := pg.Tree.Root().Children[0]
+	if len(e4.Children) != 2 {
+		t.Fatalf("expected a variation after 1. e4, got %d moves", len(e4.Children))
+	}
+
+	nf3 := e4.Children[1].Children[0]
+	if err := pg.Tree.GoTo(nf3); err != nil {
+		t.Fatalf("GoTo() error = %v", err)
+	}
+
+	if got := pg.Client.FEN(); got != nf3.FEN {
+		t.Fatalf("client FEN mismatch:\n got: %s\nwant: %s", got, nf3.FEN)
+	}
+
+	if status := mustStatus(t, pg.Client, false); status.Result != ResultOngoing {
+		t.Fatalf("expected the variation to be open, got %s", status.Result)
+	}
+
+	// the recorded result holds again at the end of the main line
+	line := pg.Tree.MainLine()
+	if err := pg.Tree.GoTo(line[len(line)-1]); err != nil {
+		t.Fatalf("GoTo() error = %v", err)
+	}
+
+	if status := mustStatus(t, pg.Client, false); status.Result != ResultWhiteWins {
+		t.Fatalf("expected 1-0, got %s", status.Result)
+	}
+}
@@ -47,6 +47,24 @@ func newPiece(pt pieceType, sd Side) *Piece {
 	}
 }
 
+// newPieceFromNotation creates a piece from its algebraic notation (e.g. "Q").
+// It returns nil for notations that do not identify a piece that a pawn
+// may be promoted to.
+func newPieceFromNotation(n string, sd Side) *Piece {
+	switch n {
+	case "B":
+		return newPiece(pieceBishop, sd)
+	case "N":
+		return newPiece(pieceKnight, sd)
+	case "Q":
+		return newPiece(pieceQueen, sd)
+	case "R":
+		return newPiece(pieceRook, sd)
+	default:
+		return nil
+	}
+}
+
 // toFEN converts the piece to its Forsyth-Edwards Notation (FEN) character.
 // White pieces are uppercase (e.g., 'R'), and black pieces are lowercase (e.g., 'r').
 func (p *Piece) toFEN() string {
@@ -0,0 +1,742 @@
+package chess
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pieceCode identifies a piece of a given side on a square of a position,
+// where zero is an empty square.
+type pieceCode uint8
+
+// codeOf returns the code of a piece of the given type and side.
+func codeOf(pt pieceType, sd Side) pieceCode {
+	return pieceCode(int(sd)*6 + int(pt) + 1)
+}
+
+func (pc pieceCode) side() Side {
+	return Side((pc - 1) / 6)
+}
+
+func (pc pieceCode) kind() pieceType {
+	return pieceType((pc - 1) % 6)
+}
+
+// Move is a move within a Position: the origin and destination squares, the
+// piece a pawn is promoted to (if any) and a flag for special moves. Castling
+// moves are encoded with the square of the castling rook as the destination.
+type Move uint32
+
+const (
+	moveFlagNone      = iota // moveFlagNone is an ordinary move or capture.
+	moveFlagDouble           // moveFlagDouble is a pawn advancing two squares.
+	moveFlagEnPassant        // moveFlagEnPassant is an en passant capture.
+	moveFlagCastle           // moveFlagCastle is the king castling with a rook.
+)
+
+// newMove encodes a move. Promotion is the type of the promoted piece plus
+// one, so that zero indicates no promotion.
+func newMove(from, to int, promo int, flag int) Move {
+	return Move(from | to<<6 | promo<<12 | flag<<15)
+}
+
+// From returns the index of the origin square, where 0 is a1, 7 is h1 and 63 is h8.
+func (m Move) From() int {
+	return int(m & 0x3f)
+}
+
+// To returns the index of the destination square (the rook's square when castling).
+func (m Move) To() int {
+	return int(m>>6) & 0x3f
+}
+
+// Promotion returns the type of the promoted piece, and whether the move is a promotion.
+func (m Move) Promotion() (pieceType, bool) {
+	p := int(m>>12) & 0x7
+	return pieceType(p - 1), p != 0
+}
+
+func (m Move) flag() int {
+	return int(m>>15) & 0x7
+}
+
+// promotionTypes lists the pieces a pawn may be promoted to.
+var promotionTypes = [4]pieceType{pieceQueen, pieceRook, pieceBishop, pieceKnight}
+
+// Position is a bitboard representation of the state of a game, used to
+// generate legal moves without walking the squares of the Board. Moves made
+// on a Position maintain the castling rights, en passant target and clocks,
+// but are not recorded in any game: no events are emitted and no notation is
+// computed, which makes it suitable for search and perft.
+type Position struct {
+	c960   bool
+	cstl   castleRights
+	enP    int // enP is the en passant target square, or -1.
+	fmn    int
+	hmc    int
+	key    uint64 // key is the Zobrist key of the position, excluding the en passant target.
+	occ    [2]bitboard
+	pcs    [64]pieceCode
+	pieces [2][6]bitboard
+	shrd   bool
+	side   Side
+}
+
+// MoveState records the state of a Position before a move was made, so that
+// the move can be unmade.
+type MoveState struct {
+	cpt  pieceCode // cpt is the piece captured on the destination square, if any.
+	cstl castleRights
+	enP  int
+	hmc  int
+	key  uint64
+	m    Move
+}
+
+// newPosition creates the position of the game's board and state.
+func newPosition(g *Game) *Position {
+	p := &Position{
+		c960: g.c960,
+		cstl: g.cstl,
+		enP:  -1,
+		fmn:  g.fmn,
+		hmc:  g.hmc,
+		shrd: g.shrd,
+		side: g.getCurrentSide(),
+	}
+
+	for i, sq := range g.Board.Squares {
+		if sq.Piece != nil {
+			p.put(i, codeOf(sq.Piece.Type, sq.Piece.Side))
+		}
+	}
+
+	if g.enP != nil {
+		p.enP = g.Board.indexOf(g.enP)
+	}
+
+	p.key ^= zobristCastleRights(p.cstl)
+	if p.side == sideBlack {
+		p.key ^= zobristSide
+	}
+
+	return p
+}
+
+// Hash returns the Zobrist key of the position, including the file of the
+// en passant target when an en passant capture is possible.
+func (p *Position) Hash() uint64 {
+	if p.enP >= 0 && p.canCaptureEnPassant() {
+		return p.key ^ zobristEnPassant[p.enP%8]
+	}
+
+	return p.key
+}
+
+// canCaptureEnPassant reports whether the side to move has a legal en passant capture.
+func (p *Position) canCaptureEnPassant() bool {
+	if p.enP < 0 {
+		return false
+	}
+
+	for pawns := pawnAttacks[p.side.Opponent()][p.enP] & p.pieces[p.side][piecePawn]; pawns != 0; {
+		if p.isLegal(newMove(pawns.pop(), p.enP, 0, moveFlagEnPassant)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// put places a piece on an empty square.
+func (p *Position) put(sq int, pc pieceCode) {
+	bb := squareBit(sq)
+	p.key ^= zobristPieces[pc-1][sq]
+	p.pcs[sq] = pc
+	p.occ[pc.side()] |= bb
+	p.pieces[pc.side()][pc.kind()] |= bb
+}
+
+// remove lifts the piece from a square.
+func (p *Position) remove(sq int) {
+	pc := p.pcs[sq]
+	if pc == 0 {
+		return
+	}
+
+	bb := squareBit(sq)
+	p.key ^= zobristPieces[pc-1][sq]
+	p.pcs[sq] = 0
+	p.occ[pc.side()] &^= bb
+	p.pieces[pc.side()][pc.kind()] &^= bb
+}
+
+// occupied returns every occupied square.
+func (p *Position) occupied() bitboard {
+	return p.occ[sideWhite] | p.occ[sideBlack]
+}
+
+// kingSquare returns the square of the given side's king, or -1.
+func (p *Position) kingSquare(sd Side) int {
+	if k := p.pieces[sd][pieceKing]; k != 0 {
+		return k.first()
+	}
+
+	return -1
+}
+
+// attackersTo returns the pieces of the given side attacking sq, when the
+// occupied squares are occ.
+func (p *Position) attackersTo(sq int, occ bitboard, by Side) bitboard {
+	pcs := &p.pieces[by]
+	queens := pcs[pieceQueen]
+
+	return knightAttacks[sq]&pcs[pieceKnight] |
+		kingAttacks[sq]&pcs[pieceKing] |
+		pawnAttacks[by.Opponent()][sq]&pcs[piecePawn] |
+		bishopAttacks(sq, occ)&(pcs[pieceBishop]|queens) |
+		rookAttacks(sq, occ)&(pcs[pieceRook]|queens)
+}
+
+// checkers returns the pieces giving check to the side to move.
+func (p *Position) checkers() bitboard {
+	k := p.kingSquare(p.side)
+	if k < 0 {
+		return 0
+	}
+
+	return p.attackersTo(k, p.occupied(), p.side.Opponent())
+}
+
+// InCheck reports whether the side to move is in check.
+func (p *Position) InCheck() bool {
+	return p.checkers() != 0
+}
+
+// LegalMoves appends every legal move of the side to move to mvs. Passing a
+// slice with enough capacity (e.g. that of a [256]Move array) avoids any
+// allocation.
+func (p *Position) LegalMoves(mvs []Move) []Move {
+	start := len(mvs)
+	mvs = p.pseudoLegalMoves(mvs)
+
+	legal := mvs[:start]
+	for _, m := range mvs[start:] {
+		if p.isLegal(m) {
+			legal = append(legal, m)
+		}
+	}
+
+	return legal
+}
+
+// hasLegalMove reports whether the side to move has any legal move.
+func (p *Position) hasLegalMove() bool {
+	var buf [256]Move
+	for _, m := range p.pseudoLegalMoves(buf[:0]) {
+		if p.isLegal(m) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pseudoLegalMoves appends the moves of the side to move to mvs, without
+// regard to whether they leave the king in check. Castling moves are only
+// generated when they are legal.
+func (p *Position) pseudoLegalMoves(mvs []Move) []Move {
+	us, them := p.side, p.side.Opponent()
+	occ := p.occupied()
+	targets := ^p.occ[us]
+
+	// pawns
+	fwd, start, last := 8, rank1<<8, rank8
+	if us == sideBlack {
+		fwd, start, last = -8, rank8>>8, rank1
+	}
+
+	for pawns := p.pieces[us][piecePawn]; pawns != 0; {
+		from := pawns.pop()
+
+		dests := pawnAttacks[us][from] & p.occ[them]
+		if to := from + fwd; to >= 0 && to < 64 && !occ.has(to) {
+			dests |= squareBit(to)
+			if start.has(from) && !occ.has(to+fwd) {
+				mvs = append(mvs, newMove(from, to+fwd, 0, moveFlagDouble))
+			}
+		}
+
+		for dests != 0 {
+			to := dests.pop()
+			if !last.has(to) {
+				mvs = append(mvs, newMove(from, to, 0, moveFlagNone))
+				continue
+			}
+
+			for _, pt := range promotionTypes {
+				mvs = append(mvs, newMove(from, to, int(pt)+1, moveFlagNone))
+			}
+		}
+
+		if p.enP >= 0 && pawnAttacks[us][from].has(p.enP) {
+			mvs = append(mvs, newMove(from, p.enP, 0, moveFlagEnPassant))
+		}
+	}
+
+	// pieces
+	for _, pt := range []pieceType{pieceKnight, pieceBishop, pieceRook, pieceQueen, pieceKing} {
+		for pcs := p.pieces[us][pt]; pcs != 0; {
+			from := pcs.pop()
+
+			var dests bitboard
+			switch pt {
+			case pieceKnight:
+				dests = knightAttacks[from]
+			case pieceBishop:
+				dests = bishopAttacks(from, occ)
+			case pieceRook:
+				dests = rookAttacks(from, occ)
+			case pieceQueen:
+				dests = bishopAttacks(from, occ) | rookAttacks(from, occ)
+			case pieceKing:
+				dests = kingAttacks[from]
+			}
+
+			for dests &= targets; dests != 0; {
+				mvs = append(mvs, newMove(from, dests.pop(), 0, moveFlagNone))
+			}
+		}
+	}
+
+	return p.castleMoves(mvs)
+}
+
+// castleMoves appends the legal castling moves of the side to move to mvs.
+// The king and rook must stand on the back rank with every square they
+// travel across vacant, and the king may be neither in check nor pass
+// through or land upon an attacked square.
+func (p *Position) castleMoves(mvs []Move) []Move {
+	us, them := p.side, p.side.Opponent()
+	rank := backRank(us)
+
+	k := p.kingSquare(us)
+	if k < 0 || k/8 != rank-1 || p.InCheck() {
+		return mvs
+	}
+
+	occ := p.occupied()
+	for _, wing := range []int{castleKingSide, castleQueenSide} {
+		rf := p.cstl[us][wing]
+		if rf == 0 {
+			continue
+		}
+
+		r := (rank-1)*8 + int(rf-'a')
+		if p.pcs[r] != codeOf(pieceRook, us) {
+			continue
+		}
+
+		// classical castling is only available from the e-file with the corner rooks
+		if !p.c960 && (k%8 != 4 || (rf != 'a' && rf != 'h')) {
+			continue
+		}
+
+		kf, krf := castleDestinations(wing)
+		kd := (rank-1)*8 + int(kf-'a')
+		rd := (rank-1)*8 + int(krf-'a')
+
+		// every square the king and rook travel across must be vacant
+		lo, hi := min(k, r, kd, rd), max(k, r, kd, rd)
+		path := bitboard(0)
+		for sq := lo; sq <= hi; sq++ {
+			path |= squareBit(sq)
+		}
+
+		if path&occ&^squareBit(k)&^squareBit(r) != 0 {
+			continue
+		}
+
+		// the king may not pass through an attacked square...
+		step := 1
+		if kd < k {
+			step = -1
+		}
+
+		safe := true
+		for sq := k + step; safe && k != kd && sq != kd; sq += step {
+			safe = p.attackersTo(sq, occ, them) == 0
+		}
+
+		// ...nor land upon one once the rook has moved
+		after := occ&^squareBit(k)&^squareBit(r) | squareBit(kd) | squareBit(rd)
+		if !safe || p.attackersTo(kd, after, them) != 0 {
+			continue
+		}
+
+		mvs = append(mvs, newMove(k, r, 0, moveFlagCastle))
+	}
+
+	return mvs
+}
+
+// isLegal reports whether a pseudo-legal move leaves the mover's king safe.
+func (p *Position) isLegal(m Move) bool {
+	if m.flag() == moveFlagCastle {
+		return true
+	}
+
+	us, them := p.side, p.side.Opponent()
+	from, to := m.From(), m.To()
+
+	k := p.kingSquare(us)
+	if from == k {
+		k = to
+	}
+
+	if k < 0 {
+		return true
+	}
+
+	// the captured piece no longer attacks the king
+	captured := squareBit(to)
+	occ := p.occupied()&^squareBit(from) | squareBit(to)
+	if m.flag() == moveFlagEnPassant {
+		captured = squareBit(p.enPassantCaptureSquare(to))
+		occ &^= captured
+	}
+
+	return p.attackersTo(k, occ, them)&^captured == 0
+}
+
+// enPassantCaptureSquare returns the square of the pawn captured en passant
+// by the side to move on the target square.
+func (p *Position) enPassantCaptureSquare(to int) int {
+	if p.side == sideWhite {
+		return to - 8
+	}
+
+	return to + 8
+}
+
+// play makes a move, which must be legal, updating the castling rights, en
+// passant target, clocks and side to move.
+func (p *Position) play(m Move) {
+	us, them := p.side, p.side.Opponent()
+	from, to := m.From(), m.To()
+	pc := p.pcs[from]
+
+	cstl := p.cstl
+
+	p.hmc++
+	if pc.kind() == piecePawn || (p.pcs[to] != 0 && m.flag() != moveFlagCastle) {
+		p.hmc = 0
+	}
+
+	p.enP = -1
+
+	switch m.flag() {
+	case moveFlagCastle:
+		kd, rd := castleSquares(m)
+		p.remove(from)
+		p.remove(to)
+		p.put(kd, pc)
+		p.put(rd, codeOf(pieceRook, us))
+	case moveFlagEnPassant:
+		p.remove(p.enPassantCaptureSquare(to))
+		p.remove(from)
+		p.put(to, pc)
+	default:
+		if cpt := p.pcs[to]; cpt != 0 {
+			if cpt.kind() == pieceRook {
+				p.revoke(them, to)
+			}
+			p.remove(to)
+		}
+
+		p.remove(from)
+		if pt, ok := m.Promotion(); ok {
+			pc = codeOf(pt, us)
+		}
+		p.put(to, pc)
+
+		if m.flag() == moveFlagDouble {
+			p.enP = (from + to) / 2
+		}
+	}
+
+	switch pc.kind() {
+	case pieceKing:
+		p.cstl[us] = [2]rune{}
+	case pieceRook:
+		p.revoke(us, from)
+	}
+
+	if us == sideBlack {
+		p.fmn++
+	}
+	p.side = them
+	p.key ^= zobristSide ^ zobristCastleRights(cstl) ^ zobristCastleRights(p.cstl)
+}
+
+// MakeMove makes a move, which must be one of the legal moves of the
+// position, updating the castling rights, en passant target, clocks and side
+// to move. It neither allocates nor emits events; the returned state is passed
+// to UnmakeMove to restore the position.
+func (p *Position) MakeMove(m Move) MoveState {
+	st := MoveState{cstl: p.cstl, enP: p.enP, hmc: p.hmc, key: p.key, m: m}
+	if m.flag() == moveFlagNone {
+		st.cpt = p.pcs[m.To()]
+	}
+
+	p.play(m)
+
+	return st
+}
+
+// UnmakeMove restores the position from before the move recorded by the state
+// was made. Moves must be unmade in the reverse order in which they were made.
+func (p *Position) UnmakeMove(st MoveState) {
+	m := st.m
+	us := p.side.Opponent()
+	from, to := m.From(), m.To()
+
+	p.side = us
+	if us == sideBlack {
+		p.fmn--
+	}
+
+	switch m.flag() {
+	case moveFlagCastle:
+		kd, rd := castleSquares(m)
+		p.remove(kd)
+		p.remove(rd)
+		p.put(from, codeOf(pieceKing, us))
+		p.put(to, codeOf(pieceRook, us))
+	case moveFlagEnPassant:
+		p.remove(to)
+		p.put(from, codeOf(piecePawn, us))
+		p.put(p.enPassantCaptureSquare(to), codeOf(piecePawn, us.Opponent()))
+	default:
+		pc := p.pcs[to]
+		if _, ok := m.Promotion(); ok {
+			pc = codeOf(piecePawn, us)
+		}
+
+		p.remove(to)
+		p.put(from, pc)
+		if st.cpt != 0 {
+			p.put(to, st.cpt)
+		}
+	}
+
+	// the key is restored along with the state, undoing the changes made by put and remove
+	p.cstl, p.enP, p.hmc, p.key = st.cstl, st.enP, st.hmc, st.key
+}
+
+// revoke removes the given side's castling right that uses the rook on sq.
+func (p *Position) revoke(sd Side, sq int) {
+	if sq/8 != backRank(sd)-1 {
+		return
+	}
+
+	for wing, rf := range p.cstl[sd] {
+		if rf != 0 && int(rf-'a') == sq%8 {
+			p.cstl[sd][wing] = 0
+		}
+	}
+}
+
+// squares returns the origin and destination squares of a move as recorded
+// on the Board: castling moves of classical games use the king's
+// destination, while Chess960 castling moves use the rook's square.
+func (p *Position) squares(m Move) (int, int) {
+	if m.flag() != moveFlagCastle || p.c960 {
+		return m.From(), m.To()
+	}
+
+	kd, _ := castleSquares(m)
+	return m.From(), kd
+}
+
+// castleSquares returns the destination squares of the king and rook of a
+// castling move.
+func castleSquares(m Move) (int, int) {
+	wing := castleKingSide
+	if m.To() < m.From() {
+		wing = castleQueenSide
+	}
+
+	kf, krf := castleDestinations(wing)
+	rank := (m.From() / 8) * 8
+
+	return rank + int(kf-'a'), rank + int(krf-'a')
+}
+
+// UCI returns a move in UCI long algebraic notation, with castling moves
+// notated as they are recorded on the Board (see squares).
+func (p *Position) UCI(m Move) string {
+	from, to := p.squares(m)
+
+	uci := squareName(from) + squareName(to)
+	if pt, ok := m.Promotion(); ok {
+		uci += strings.ToLower(newPiece(pt, sideWhite).Notation)
+	}
+
+	return uci
+}
+
+// san returns a legal move in Standard Algebraic Notation as the
+// AlgebraicGameClient notates it: castling with the letter O, pieces that
+// share a destination told apart by file, then rank, then both, and a
+// suffix for moves that give check or checkmate.
+func (p *Position) san(m Move) string {
+	from, to := m.From(), m.To()
+	pt := p.pcs[from].kind()
+
+	var sb strings.Builder
+	switch {
+	case m.flag() == moveFlagCastle:
+		sb.WriteString("O-O")
+		if to < from {
+			sb.WriteString("-O")
+		}
+	case pt == piecePawn:
+		if from%8 != to%8 {
+			sb.WriteString(squareName(from)[:1] + "x")
+		}
+		sb.WriteString(squareName(to))
+
+		if promo, ok := m.Promotion(); ok {
+			sb.WriteString("=" + newPiece(promo, sideWhite).Notation)
+		}
+	default:
+		sb.WriteString(newPiece(pt, sideWhite).Notation)
+		if pt != pieceKing {
+			sb.WriteString(p.disambiguation(m))
+		}
+
+		if p.pcs[to] != 0 {
+			sb.WriteString("x")
+		}
+		sb.WriteString(squareName(to))
+	}
+
+	st := p.MakeMove(m)
+	switch {
+	case !p.InCheck():
+	case p.hasLegalMove():
+		sb.WriteString("+")
+	default:
+		sb.WriteString("#")
+	}
+	p.UnmakeMove(st)
+
+	return sb.String()
+}
+
+// disambiguation returns the origin file, rank or square that tells a move
+// apart from those of other pieces of the same type to the same square.
+func (p *Position) disambiguation(m Move) string {
+	from, to := m.From(), m.To()
+	ambiguous, sameFile, sameRank := false, false, false
+
+	var buf [256]Move
+	for _, o := range p.LegalMoves(buf[:0]) {
+		if of := o.From(); of != from && o.To() == to && p.pcs[of] == p.pcs[from] {
+			ambiguous = true
+			sameFile = sameFile || of%8 == from%8
+			sameRank = sameRank || of/8 == from/8
+		}
+	}
+
+	sq := squareName(from)
+	switch {
+	case !ambiguous:
+		return ""
+	case !sameFile:
+		return sq[:1]
+	case !sameRank:
+		return sq[1:]
+	default:
+		return sq
+	}
+}
+
+// FEN returns the Forsyth-Edwards Notation (FEN) of the position.
+func (p *Position) FEN() string {
+	var plc strings.Builder
+	for r := 7; r >= 0; r-- {
+		ec := 0
+		for f := range 8 {
+			pc := p.pcs[r*8+f]
+			if pc == 0 {
+				ec++
+				continue
+			}
+
+			if ec > 0 {
+				plc.WriteString(strconv.Itoa(ec))
+				ec = 0
+			}
+			plc.WriteString(newPiece(pc.kind(), pc.side()).toFEN())
+		}
+
+		if ec > 0 {
+			plc.WriteString(strconv.Itoa(ec))
+		}
+
+		if r > 0 {
+			plc.WriteRune('/')
+		}
+	}
+
+	// castling rights are notated relative to the rooks on the board
+	b, err := loadBoard(plc.String())
+	if err != nil {
+		return ""
+	}
+
+	sd, enP := "w", "-"
+	if p.side == sideBlack {
+		sd = "b"
+	}
+
+	if p.enP >= 0 {
+		enP = squareName(p.enP)
+	}
+
+	return fmt.Sprintf("%s %s %s %s %d %d", plc.String(), sd, p.cstl.fen(b, p.shrd), enP, p.hmc, p.fmn)
+}
+
+// squareName returns the name of a square of a position (e.g. "e4").
+func squareName(sq int) string {
+	return string(rune('a'+sq%8)) + string(rune('1'+sq/8))
+}
+
+// potentialMoves groups legal moves by origin square, as squares of the board.
+func (p *Position) potentialMoves(b *Board, mvs []Move) []potentialMoves {
+	var dests [64][]*Square
+	for _, m := range mvs {
+		// promotions to each piece share the same destination
+		if pt, ok := m.Promotion(); ok && pt != pieceQueen {
+			continue
+		}
+
+		from, to := p.squares(m)
+		dests[from] = append(dests[from], b.Squares[to])
+	}
+
+	pms := []potentialMoves{}
+	for sq, ds := range dests {
+		if len(ds) > 0 {
+			pms = append(pms, potentialMoves{
+				destinationSquares: ds,
+				origin:             b.Squares[sq],
+			})
+		}
+	}
+
+	return pms
+}
@@ -0,0 +1,270 @@
+package chess
+
+import (
+	"slices"
+	"testing"
+)
+
+// generatorPositions are positions exercising castling, en passant, promotion and pins.
+var generatorPositions = []struct {
+	name string
+	fen  string
+	c960 bool
+}{
+	{"initial", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", false},
+	{"kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", false},
+	{"en passant pins", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", false},
+	{"promotions", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", false},
+	{"castling through check", "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", false},
+	{"chess960", "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9", true},
+}
+
+// generatedMoves returns the moves of a validator in coordinate form, sorted.
+func generatedMoves(t *testing.T, gen func() ([]potentialMoves, *Square, error)) []string {
+	t.Helper()
+
+	pms, _, err := gen()
+	if err != nil {
+		t.Fatalf("move generation failed: %v", err)
+	}
+
+	mvs := []string{}
+	for _, pm := range pms {
+		for _, dest := range pm.destinationSquares {
+			mvs = append(mvs, pm.origin.name()+dest.name())
+		}
+	}
+	slices.Sort(mvs)
+
+	return mvs
+}
+
+func TestPositionMatchesSquareGenerator(t *testing.T) {
+	for _, tc := range generatorPositions {
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := CreateAlgebraicGameClientFromFEN(tc.fen, AlgebraicClientOptions{Chess960: tc.c960})
+			if err != nil {
+				t.Fatalf("failed to load FEN: %v", err)
+			}
+
+			// compare both generators at every position within two plies
+			var walk func(depth int)
+			walk = func(depth int) {
+				bv := CreateBoardValidator(client.game)
+				want := generatedMoves(t, bv.squareLegalMoves)
+				got := generatedMoves(t, bv.legalMoves)
+				if !slices.Equal(got, want) {
+					t.Fatalf("%s: expected moves %v, got %v", client.FEN(), want, got)
+				}
+
+				if depth == 0 {
+					return
+				}
+
+				for uci := range client.uciMoves {
+					if _, err := client.Move(uci); err != nil {
+						t.Fatalf("%s: move %s failed: %v", client.FEN(), uci, err)
+					}
+					walk(depth - 1)
+					if err := client.Undo(); err != nil {
+						t.Fatalf("undo failed: %v", err)
+					}
+				}
+			}
+
+			walk(2)
+		})
+	}
+}
+
+func TestPositionSANMatchesClient(t *testing.T) {
+	for _, tc := range generatorPositions {
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := CreateAlgebraicGameClientFromFEN(tc.fen, AlgebraicClientOptions{Chess960: tc.c960})
+			if err != nil {
+				t.Fatalf("failed to load FEN: %v", err)
+			}
+
+			// compare the notation of every move within two plies
+			var walk func(depth int)
+			walk = func(depth int) {
+				p := client.Position()
+				for _, m := range p.LegalMoves(nil) {
+					want, err := client.UCIToSAN(p.UCI(m))
+					if err != nil {
+						t.Fatalf("%s: %v", client.FEN(), err)
+					}
+
+					if got := p.san(m); got != want {
+						t.Fatalf("%s: expected %s, got %s", client.FEN(), want, got)
+					}
+				}
+
+				if depth == 0 {
+					return
+				}
+
+				for uci := range client.uciMoves {
+					if _, err := client.Move(uci); err != nil {
+						t.Fatalf("%s: move %s failed: %v", client.FEN(), uci, err)
+					}
+					walk(depth - 1)
+					if err := client.Undo(); err != nil {
+						t.Fatalf("undo failed: %v", err)
+					}
+				}
+			}
+
+			walk(2)
+		})
+	}
+}
+
+func TestPositionPlay(t *testing.T) {
+	client := CreateAlgebraicGameClient()
+	for _, ntn := range []string{"e4", "d5", "exd5", "c5", "dxc6", "Nf6", "cxb7", "Bg4", "bxa8=N", "Nc6", "Nf3", "e5", "Bc4", "Bc5", "0-0"} {
+		pos := newPosition(client.game)
+		nm := client.notatedMoves[client.keys[sanitizeNotation(ntn, false)]]
+		from, to := client.game.Board.indexOf(nm.Src), client.game.Board.indexOf(nm.Dest)
+
+		for _, m := range pos.LegalMoves(nil) {
+			if f, d := pos.squares(m); f != from || d != to {
+				continue
+			}
+			if pt, ok := m.Promotion(); ok && pt != pieceKnight {
+				continue
+			}
+			pos.play(m)
+		}
+
+		mustMove(t, client, ntn)
+
+		// the played position matches the position of the game
+		if want := newPosition(client.game); *pos != *want {
+			t.Fatalf("after %s: expected position of %s", ntn, client.FEN())
+		}
+	}
+}
+
+func TestPositionMakeUnmakeMove(t *testing.T) {
+	for _, tc := range generatorPositions {
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := CreateAlgebraicGameClientFromFEN(tc.fen, AlgebraicClientOptions{Chess960: tc.c960})
+			if err != nil {
+				t.Fatalf("failed to load FEN: %v", err)
+			}
+
+			pos := client.Position()
+			orig := *pos
+
+			for _, m := range pos.LegalMoves(nil) {
+				uci := pos.UCI(m)
+				st := pos.MakeMove(m)
+
+				if _, err := client.MoveUCI(uci); err != nil {
+					t.Fatalf("move %s failed: %v", uci, err)
+				}
+
+				// castling rights, en passant target, clocks and key match the game
+				if want := client.Position(); *pos != *want {
+					t.Fatalf("after %s: expected position of %s, got %s", uci, client.FEN(), pos.FEN())
+				}
+				if got := pos.FEN(); got != client.FEN() {
+					t.Fatalf("after %s: expected FEN %s, got %s", uci, client.FEN(), got)
+				}
+
+				pos.UnmakeMove(st)
+				if *pos != orig {
+					t.Fatalf("after unmaking %s: expected %s, got %s", uci, tc.fen, pos.FEN())
+				}
+
+				if err := client.Undo(); err != nil {
+					t.Fatalf("undo failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestPositionMakeMoveAllocations(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN(generatorPositions[1].fen)
+	if err != nil {
+		t.Fatalf("failed to load FEN: %v", err)
+	}
+
+	pos := client.Position()
+	var buf [256]Move
+
+	allocs := testing.AllocsPerRun(100, func() {
+		for _, m := range pos.LegalMoves(buf[:0]) {
+			st := pos.MakeMove(m)
+			pos.InCheck()
+			pos.Hash()
+			pos.UnmakeMove(st)
+		}
+	})
+
+	if allocs != 0 {
+		t.Fatalf("expected no allocations, got %v", allocs)
+	}
+}
+
+func BenchmarkLegalMoves(b *testing.B) {
+	for _, gen := range []string{"squares", "bitboards"} {
+		b.Run(gen, func(b *testing.B) {
+			for _, tc := range generatorPositions {
+				client, err := CreateAlgebraicGameClientFromFEN(tc.fen, AlgebraicClientOptions{Chess960: tc.c960})
+				if err != nil {
+					b.Fatalf("failed to load FEN: %v", err)
+				}
+
+				b.Run(tc.name, func(b *testing.B) {
+					bv := CreateBoardValidator(client.game)
+					legalMoves := bv.legalMoves
+					if gen == "squares" {
+						legalMoves = bv.squareLegalMoves
+					}
+
+					for b.Loop() {
+						if _, _, err := legalMoves(); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
+func BenchmarkClientMove(b *testing.B) {
+	client, err := CreateAlgebraicGameClientFromFEN(generatorPositions[1].fen)
+	if err != nil {
+		b.Fatalf("failed to load FEN: %v", err)
+	}
+
+	for b.Loop() {
+		if _, err := client.Move("Nxf7"); err != nil {
+			b.Fatal(err)
+		}
+		if err := client.Undo(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMakeUnmakeMove(b *testing.B) {
+	client, err := CreateAlgebraicGameClientFromFEN(generatorPositions[1].fen)
+	if err != nil {
+		b.Fatalf("failed to load FEN: %v", err)
+	}
+
+	pos := client.Position()
+	var buf [256]Move
+	mvs := pos.LegalMoves(buf[:0])
+
+	for b.Loop() {
+		for _, m := range mvs {
+			pos.UnmakeMove(pos.MakeMove(m))
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package chess
+
+import "testing"
+
+func TestThreefoldRepetitionIncludesStartingPosition(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+
+	var events []*RepetitionEvent
+	client.On("repetition", func(data interface{}) {
+		if ev, ok := data.(*RepetitionEvent); ok {
+			events = append(events, ev)
+		}
+	})
+
+	for i, mv := range []string{"Nf3", "Nf6", "Ng1", "Ng8", "Nf3", "Nf6", "Ng1", "Ng8"} {
+		if mustStatus(t, client, false).IsRepetition {
+			t.Fatalf("unexpected repetition before ply %d", i+1)
+		}
+		mustMove(t, client, mv)
+	}
+
+	status := mustStatus(t, client, false)
+	if !status.IsRepetition {
+		t.Fatalf("expected threefold repetition of the starting position")
+	}
+	if status.IsFivefoldRepetition {
+		t.Fatalf("expected IsFivefoldRepetition false")
+	}
+	if len(events) != 1 || events[0].Count != 3 {
+		t.Fatalf("expected a repetition event with count 3, got %v", events)
+	}
+}
+
+func TestFivefoldRepetition(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+
+	triggered := false
+	client.On("fivefoldRepetition", func(data interface{}) {
+		if ev, ok := data.(*RepetitionEvent); ok && ev.Count == 5 {
+			triggered = true
+		}
+	})
+
+	for i := 0; i < 4; i++ {
+		for _, mv := range []string{"Nc3", "Nc6", "Nb1", "Nb8"} {
+			mustMove(t, client, mv)
+		}
+	}
+
+	status := mustStatus(t, client, false)
+	if !status.IsFivefoldRepetition || !status.IsRepetition {
+		t.Fatalf("expected fivefold repetition, got %#v", status)
+	}
+	if !triggered {
+		t.Fatalf("expected fivefoldRepetition event")
+	}
+}
+
+func TestRepetitionRequiresSameCastlingRights(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN("r3k3/8/8/8/8/8/8/R3K3 w Qq - 0 1")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	// the rooks return to their squares without the right to castle, so the
+	// starting position is not repeated
+	for i := 0; i < 2; i++ {
+		for _, mv := range []string{"Ra2", "Ra7", "Ra1", "Ra8"} {
+			mustMove(t, client, mv)
+		}
+	}
+
+	if mustStatus(t, client, false).IsRepetition {
+		t.Fatalf("expected positions with different castling rights to differ")
+	}
+
+	for _, mv := range []string{"Ra2", "Ra7", "Ra1", "Ra8"} {
+		mustMove(t, client, mv)
+	}
+
+	if !mustStatus(t, client, false).IsRepetition {
+		t.Fatalf("expected threefold repetition")
+	}
+}
+
+func TestRepetitionIgnoresUncapturableEnPassantTarget(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN("4k3/8/8/8/8/8/4P3/4K3 w - - 0 1")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	for _, mv := range []string{"e4", "Kd8", "Kd1", "Ke8", "Ke1", "Kd8", "Kd1", "Ke8", "Ke1"} {
+		mustMove(t, client, mv)
+	}
+
+	if !mustStatus(t, client, false).IsRepetition {
+		t.Fatalf("expected threefold repetition when en passant is not possible")
+	}
+}
+
+func TestRepetitionRespectsCapturableEnPassantTarget(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN("4k3/8/8/8/3p4/8/4P3/4K3 w - - 0 1")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	for _, mv := range []string{"e4", "Kd8", "Kd1", "Ke8", "Ke1", "Kd8", "Kd1", "Ke8", "Ke1"} {
+		mustMove(t, client, mv)
+	}
+
+	if mustStatus(t, client, false).IsRepetition {
+		t.Fatalf("expected the position with an en passant capture to differ")
+	}
+}
+
+func TestRepetitionRequiresSameSideToMove(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN("4k3/8/8/8/8/8/8/4K2R w - - 0 1")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	// the rook cycles over three squares while the king cycles over two, so the
+	// starting placement recurs once with black to move
+	for _, mv := range []string{"Rh2", "Kd8", "Rh3", "Ke8", "Rh1", "Kd8", "Rh2", "Ke8", "Rh3", "Kd8", "Rh1", "Ke8"} {
+		mustMove(t, client, mv)
+	}
+
+	if mustStatus(t, client, false).IsRepetition {
+		t.Fatalf("expected no repetition")
+	}
+}
+
+func TestFENEnPassantTargetIsCapturable(t *testing.T) {
+	fen := "4k3/8/8/8/3pP3/8/8/4K3 b - e3 0 1"
+	client, err := CreateAlgebraicGameClientFromFEN(fen)
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	if got := client.FEN(); got != fen {
+		t.Fatalf("FEN mismatch:\n got: %s\nwant: %s", got, fen)
+	}
+
+	res := mustMove(t, client, "dxe3")
+	if !res.Move.EnPassant {
+		t.Fatalf("expected en passant capture")
+	}
+	if got := client.FEN(); got != "4k3/8/8/8/8/4p3/8/4K3 w - - 0 2" {
+		t.Fatalf("unexpected FEN after en passant: %s", got)
+	}
+}
@@ -0,0 +1,159 @@
+package chess
+
+import "errors"
+
+// ErrGameOver is returned when a move is attempted after the game has ended.
+var ErrGameOver = errors.New("game is over")
+
+// Result represents the outcome of a game.
+type Result int
+
+const (
+	// ResultOngoing indicates the game has not yet ended.
+	ResultOngoing Result = iota
+	// ResultWhiteWins indicates white has won the game (1-0).
+	ResultWhiteWins
+	// ResultBlackWins indicates black has won the game (0-1).
+	ResultBlackWins
+	// ResultDraw indicates the game was drawn (1/2-1/2).
+	ResultDraw
+)
+
+// String returns the result as a PGN game termination marker ("1-0", "0-1",
+// "1/2-1/2" or "*").
+func (r Result) String() string {
+	switch r {
+	case ResultWhiteWins:
+		return "1-0"
+	case ResultBlackWins:
+		return "0-1"
+	case ResultDraw:
+		return "1/2-1/2"
+	default:
+		return "*"
+	}
+}
+
+// resultFromPGN converts a PGN game termination marker into a Result.
+func resultFromPGN(r string) Result {
+	for _, res := range []Result{ResultWhiteWins, ResultBlackWins, ResultDraw} {
+		if res.String() == r {
+			return res
+		}
+	}
+
+	return ResultOngoing
+}
+
+// winFor returns the result of a game won by the given side.
+func winFor(sd Side) Result {
+	if sd == sideWhite {
+		return ResultWhiteWins
+	}
+
+	return ResultBlackWins
+}
+
+// Termination represents the reason a game ended.
+type Termination int
+
+const (
+	// TerminationNone indicates the game has not yet ended.
+	TerminationNone Termination = iota
+	// TerminationCheckmate indicates a player was checkmated.
+	TerminationCheckmate
+	// TerminationStalemate indicates the player to move had no legal moves and was not in check.
+	TerminationStalemate
+	// TerminationInsufficientMaterial indicates neither player could checkmate.
+	TerminationInsufficientMaterial
+	// TerminationThreefoldRepetition indicates a draw was claimed after a position occurred three times.
+	TerminationThreefoldRepetition
+	// TerminationFivefoldRepetition indicates a position occurred five times.
+	TerminationFivefoldRepetition
+	// TerminationFiftyMoveRule indicates a draw was claimed under the fifty-move rule.
+	TerminationFiftyMoveRule
+	// TerminationSeventyFiveMoveRule indicates seventy-five moves were played without a capture or pawn move.
+	TerminationSeventyFiveMoveRule
+	// TerminationResignation indicates a player resigned.
+	TerminationResignation
+	// TerminationAgreement indicates the players agreed to a draw.
+	TerminationAgreement
+	// TerminationTimeout indicates a player ran out of time.
+	TerminationTimeout
+	// TerminationUnknown indicates the game ended for a reason that was not recorded (e.g. an imported PGN).
+	TerminationUnknown
+)
+
+var terminationNames = map[Termination]string{
+	TerminationNone:                 "none",
+	TerminationCheckmate:            "checkmate",
+	TerminationStalemate:            "stalemate",
+	TerminationInsufficientMaterial: "insufficient material",
+	TerminationThreefoldRepetition:  "threefold repetition",
+	TerminationFivefoldRepetition:   "fivefold repetition",
+	TerminationFiftyMoveRule:        "fifty-move rule",
+	TerminationSeventyFiveMoveRule:  "seventy-five-move rule",
+	TerminationResignation:          "resignation",
+	TerminationAgreement:            "agreement",
+	TerminationTimeout:              "timeout",
+	TerminationUnknown:              "unknown",
+}
+
+// String returns a human readable description of the termination reason.
+func (t Termination) String() string {
+	if n, ok := terminationNames[t]; ok {
+		return n
+	}
+
+	return terminationNames[TerminationUnknown]
+}
+
+// isAutomatic reports whether the game ends on its own with this termination,
+// as opposed to by a claim or an action of the players. Automatic
+// terminations are re-evaluated whenever the position changes.
+func (t Termination) isAutomatic() bool {
+	switch t {
+	case TerminationCheckmate,
+		TerminationStalemate,
+		TerminationInsufficientMaterial,
+		TerminationFivefoldRepetition,
+		TerminationSeventyFiveMoveRule:
+		return true
+	default:
+		return false
+	}
+}
+
+// recordedResult is a result recorded by the players rather than reached on
+// the board, along with the moves that had been played when it was recorded.
+type recordedResult struct {
+	line []string
+	res  Result
+	term Termination
+}
+
+// newRecordedResult records a result after the moves of a game history.
+func newRecordedResult(res Result, term Termination, hist []*MoveEvent) *recordedResult {
+	line := make([]string, len(hist))
+	for i, mv := range hist {
+		line[i] = mv.Algebraic
+	}
+
+	return &recordedResult{line: line, res: res, term: term}
+}
+
+// holds reports whether the result applies to a game history: every move
+// it was recorded after has been played, possibly followed by others.
+func (r *recordedResult) holds(hist []*MoveEvent) bool {
+	if r == nil || len(hist) < len(r.line) {
+		return false
+	}
+
+	for i, san := range r.line {
+		if hist[i].Algebraic != san {
+			return false
+		}
+	}
+
+	return true
+}
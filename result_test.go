@@ -0,0 +1,238 @@
+package chess
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResultOngoing(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+
+	status := mustStatus(t, client, false)
+	if status.Result != ResultOngoing || status.Termination != TerminationNone {
+		t.Fatalf("expected ongoing game, got %s (%s)", status.Result, status.Termination)
+	}
+	if status.Result.String() != "*" {
+		t.Fatalf("expected *, got %s", status.Result)
+	}
+}
+
+func TestResultCheckmate(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+
+	var event *GameOverEvent
+	client.On("gameOver", func(data interface{}) {
+		if ev, ok := data.(*GameOverEvent); ok {
+			event = ev
+		}
+	})
+
+	for _, mv := range []string{"f3", "e5", "g4", "Qh4#"} {
+		mustMove(t, client, mv)
+	}
+
+	status := mustStatus(t, client, false)
+	if status.Result != ResultBlackWins || status.Termination != TerminationCheckmate {
+		t.Fatalf("expected 0-1 by checkmate, got %s (%s)", status.Result, status.Termination)
+	}
+	if event == nil || event.Result != ResultBlackWins {
+		t.Fatalf("expected gameOver event")
+	}
+}
+
+func TestResultStalemateAndInsufficientMaterial(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN("7k/5Q2/6K1/8/8/8/8/8 b - - 0 1")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	status := mustStatus(t, client, false)
+	if status.Result != ResultDraw || status.Termination != TerminationStalemate {
+		t.Fatalf("expected draw by stalemate, got %s (%s)", status.Result, status.Termination)
+	}
+
+	client, err = CreateAlgebraicGameClientFromFEN("4k3/8/8/8/8/8/8/4KB2 w - - 0 1")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	status = mustStatus(t, client, false)
+	if status.Result != ResultDraw || status.Termination != TerminationInsufficientMaterial {
+		t.Fatalf("expected draw by insufficient material, got %s (%s)", status.Result, status.Termination)
+	}
+}
+
+func TestMoveRefusedAfterGameOver(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+
+	mustMove(t, client, "e4")
+	if err := client.Resign(Black); err != nil {
+		t.Fatalf("Resign() error = %v", err)
+	}
+
+	status := mustStatus(t, client, false)
+	if status.Result != ResultWhiteWins || status.Termination != TerminationResignation {
+		t.Fatalf("expected 1-0 by resignation, got %s (%s)", status.Result, status.Termination)
+	}
+
+	if _, err := client.Move("e5"); !errors.Is(err, ErrGameOver) {
+		t.Fatalf("expected ErrGameOver, got %v", err)
+	}
+	if _, err := client.MoveUCI("e7e5"); !errors.Is(err, ErrGameOver) {
+		t.Fatalf("expected ErrGameOver, got %v", err)
+	}
+	if err := client.AgreeDraw(); !errors.Is(err, ErrGameOver) {
+		t.Fatalf("expected ErrGameOver, got %v", err)
+	}
+}
+
+func TestMoveAfterGameOverOptOut(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{AllowMovesAfterGameOver: true})
+
+	if err := client.AgreeDraw(); err != nil {
+		t.Fatalf("AgreeDraw() error = %v", err)
+	}
+
+	mustMove(t, client, "e4")
+
+	status := mustStatus(t, client, false)
+	if status.Result != ResultDraw || status.Termination != TerminationAgreement {
+		t.Fatalf("expected draw by agreement to be kept, got %s (%s)", status.Result, status.Termination)
+	}
+}
+
+func TestUndoReopensGame(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+
+	for _, mv := range []string{"f3", "e5", "g4"} {
+		mustMove(t, client, mv)
+	}
+	mustMove(t, client, "Qh4#").Undo()
+
+	status := mustStatus(t, client, false)
+	if status.Result != ResultOngoing || status.Termination != TerminationNone {
+		t.Fatalf("expected undo to re-open the game, got %s (%s)", status.Result, status.Termination)
+	}
+
+	mustMove(t, client, "Nc6")
+}
+
+func TestUndoReopensRecordedResult(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+
+	mustMove(t, client, "e4")
+	res := mustMove(t, client, "e5")
+	if err := client.Resign(White); err != nil {
+		t.Fatalf("Resign() error = %v", err)
+	}
+
+	res.Undo()
+
+	status := mustStatus(t, client, false)
+	if status.Result != ResultOngoing || status.Termination != TerminationNone {
+		t.Fatalf("expected undo to re-open the game, got %s (%s)", status.Result, status.Termination)
+	}
+
+	// replaying the move restores the resignation, while another move does not
+	res = mustMove(t, client, "e5")
+
+	status = mustStatus(t, client, false)
+	if status.Result != ResultBlackWins || status.Termination != TerminationResignation {
+		t.Fatalf("expected 0-1 by resignation, got %s (%s)", status.Result, status.Termination)
+	}
+
+	res.Undo()
+	mustMove(t, client, "c5")
+
+	status = mustStatus(t, client, false)
+	if status.Result != ResultOngoing {
+		t.Fatalf("expected the game to continue, got %s (%s)", status.Result, status.Termination)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+
+	if err := client.Timeout(White); err != nil {
+		t.Fatalf("Timeout() error = %v", err)
+	}
+
+	status := mustStatus(t, client, false)
+	if status.Result != ResultBlackWins || status.Termination != TerminationTimeout {
+		t.Fatalf("expected 0-1 on time, got %s (%s)", status.Result, status.Termination)
+	}
+
+	// the opponent of the side that ran out of time cannot checkmate
+	client, err := CreateAlgebraicGameClientFromFEN("4k3/8/8/8/8/8/8/3QK3 w - - 0 1")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	if err := client.Timeout(White); err != nil {
+		t.Fatalf("Timeout() error = %v", err)
+	}
+
+	status = mustStatus(t, client, false)
+	if status.Result != ResultDraw || status.Termination != TerminationTimeout {
+		t.Fatalf("expected draw on time, got %s (%s)", status.Result, status.Termination)
+	}
+}
+
+func TestClaimDraw(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+
+	if err := client.ClaimDraw(); err == nil {
+		t.Fatalf("expected claim to be refused")
+	}
+
+	for _, mv := range []string{"Nf3", "Nf6", "Ng1", "Ng8", "Nf3", "Nf6", "Ng1", "Ng8"} {
+		mustMove(t, client, mv)
+	}
+
+	if err := client.ClaimDraw(); err != nil {
+		t.Fatalf("ClaimDraw() error = %v", err)
+	}
+
+	status := mustStatus(t, client, false)
+	if status.Result != ResultDraw || status.Termination != TerminationThreefoldRepetition {
+		t.Fatalf("expected draw by repetition, got %s (%s)", status.Result, status.Termination)
+	}
+}
+
+func TestResultFromPGN(t *testing.T) {
+	pg, err := ParsePGN(`[Result "0-1"]
+[Termination "time forfeit"]
+
+1. e4 e5 0-1`)
+	if err != nil {
+		t.Fatalf("ParsePGN() error = %v", err)
+	}
+
+	status := mustStatus(t, pg.Client, false)
+	if status.Result != ResultBlackWins || status.Termination != TerminationTimeout {
+		t.Fatalf("expected 0-1 on time, got %s (%s)", status.Result, status.Termination)
+	}
+}
+
+func TestPGNExportUsesResult(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+
+	mustMove(t, client, "e4")
+	if err := client.Resign(White); err != nil {
+		t.Fatalf("Resign() error = %v", err)
+	}
+
+	exp := `[Event "?"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "?"]
+[Black "?"]
+[Result "0-1"]
+
+1. e4 0-1
+`
+	if got := client.PGN(); got != exp {
+		t.Fatalf("PGN mismatch:\n got: %s\nwant: %s", got, exp)
+	}
+}
@@ -0,0 +1,523 @@
+package chess
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	maxSearchDepth     = 64                       // maxSearchDepth is the deepest iteration of a search.
+	maxSearchPly       = 128                      // maxSearchPly bounds the plies below the root, including extensions.
+	mateScore          = 30000                    // mateScore is the score of delivering checkmate at the root.
+	mateThreshold      = mateScore - maxSearchPly // mateThreshold is the lowest score of a forced mate.
+	searchInfinity     = 32000                    // searchInfinity bounds every score.
+	searchTableSize    = 1 << 18                  // searchTableSize is the number of entries of the transposition table.
+	searchCheckNodes   = 1024                     // searchCheckNodes is the interval, in nodes, at which the time and context are checked.
+	captureOrder       = 1 << 20                  // captureOrder is added to the order of captures, so they are searched before quiet moves.
+	hashMoveOrder      = 1 << 30                  // hashMoveOrder is the order of the move stored in the transposition table.
+	killerOrder        = captureOrder - 1000      // killerOrder is the order of quiet moves that caused a cutoff at the same ply.
+	losingCaptureOrder = -captureOrder            // losingCaptureOrder is added to the exchange value of captures that lose material, so they are searched last.
+)
+
+// bounds of the scores stored in the transposition table
+const (
+	boundExact = iota + 1 // boundExact is a score within the search window.
+	boundLower            // boundLower is a score at least as high as stored (a cutoff).
+	boundUpper            // boundUpper is a score at most as high as stored (no move raised alpha).
+)
+
+// SearchLimits bounds the search for a best move. Fields left at zero do not
+// limit the search, which ends at the maximum depth or once the context is
+// cancelled.
+type SearchLimits struct {
+	Depth int           // Depth is the maximum depth of the search in plies.
+	Nodes uint64        // Nodes is the maximum number of positions searched.
+	Time  time.Duration // Time is the maximum duration of the search.
+}
+
+// SearchResult describes the best move found by a search.
+type SearchResult struct {
+	Depth int           // Depth is the depth in plies of the deepest completed iteration.
+	Mate  int           // Mate is the number of moves to a forced mate, negative when the side to move is mated (0 when none was found).
+	Move  string        // Move is the best move in Standard Algebraic Notation (e.g. "Nf3").
+	Nodes uint64        // Nodes is the number of positions searched.
+	PV    []string      // PV is the principal variation, the expected line of play beginning with the best move, in UCI notation.
+	Score int           // Score is the evaluation in centipawns from the point of view of the side to move.
+	Time  time.Duration // Time is the duration of the search.
+	UCI   string        // UCI is the best move in UCI long algebraic notation (e.g. "g1f3").
+}
+
+// ttEntry is an entry of the transposition table.
+type ttEntry struct {
+	bound uint8
+	depth int8
+	key   uint64
+	move  Move
+	score int32
+}
+
+// searcher performs an iterative deepening alpha-beta search of a position.
+type searcher struct {
+	ctx      context.Context
+	deadline time.Time
+	history  [2][64][64]int
+	keys     []uint64 // keys are the Zobrist keys of the positions preceding the current one.
+	killers  [maxSearchPly][2]Move
+	limits   SearchLimits
+	nodes    uint64
+	pos      *Position
+	pv       [maxSearchPly][maxSearchPly]Move
+	pvLen    [maxSearchPly]int
+	report   func(*SearchResult) // report, when set, receives the result of each completed iteration.
+	stopped  bool
+	tt       []ttEntry
+}
+
+// newSearcher creates a searcher of the position, where keys are the Zobrist
+// keys of the positions of the game that preceded it.
+func newSearcher(ctx context.Context, pos *Position, keys []uint64, limits SearchLimits) *searcher {
+	s := &searcher{
+		ctx:    ctx,
+		keys:   make([]uint64, len(keys), len(keys)+maxSearchPly),
+		limits: limits,
+		pos:    pos,
+		tt:     make([]ttEntry, searchTableSize),
+	}
+	copy(s.keys, keys)
+
+	if limits.Time > 0 {
+		s.deadline = time.Now().Add(limits.Time)
+	}
+
+	return s
+}
+
+// BestMove searches the current position for the best move of the side to
+// move, using iterative deepening until a limit is reached or the context is
+// cancelled. The best move of the deepest completed iteration is returned,
+// so cancelling the context ends the search without failing it. An error is
+// returned when the side to move has no legal moves.
+func (c *AlgebraicGameClient) BestMove(ctx context.Context, limits SearchLimits) (*SearchResult, error) {
+	return c.bestMove(ctx, limits, nil)
+}
+
+// bestMove searches the current position, passing the result of each
+// completed iteration to report when it is not nil.
+func (c *AlgebraicGameClient) bestMove(ctx context.Context, limits SearchLimits, report func(*SearchResult)) (*SearchResult, error) {
+	if len(c.uciMoves) == 0 {
+		return nil, errors.New("no legal moves in the current position")
+	}
+
+	// positions that occurred earlier in the game are draws by repetition
+	var keys []uint64
+	if n := len(c.game.MoveHistory); n > 0 {
+		keys = append(keys, c.game.init)
+		for _, mv := range c.game.MoveHistory[:n-1] {
+			keys = append(keys, mv.hashCode)
+		}
+	}
+
+	s := newSearcher(ctx, newPosition(c.game), keys, limits)
+	s.report = report
+	res := s.search()
+
+	san, err := c.UCIToSAN(res.UCI)
+	if err != nil {
+		return nil, err
+	}
+	res.Move = san
+
+	return res, nil
+}
+
+// search deepens the search one ply at a time, keeping the result of each
+// completed iteration.
+func (s *searcher) search() *SearchResult {
+	start := time.Now()
+
+	// the first legal move stands in should no iteration complete
+	var buf [256]Move
+	res := &SearchResult{UCI: s.pos.UCI(s.pos.LegalMoves(buf[:0])[0])}
+
+	depth := maxSearchDepth
+	if s.limits.Depth > 0 {
+		depth = min(s.limits.Depth, maxSearchDepth)
+	}
+
+	for d := 1; d <= depth; d++ {
+		score := s.negamax(d, 0, -searchInfinity, searchInfinity)
+		if s.stopped || s.pvLen[0] == 0 {
+			break
+		}
+
+		res.Depth = d
+		res.Score = score
+		res.Mate = mateIn(score)
+		res.UCI = s.pos.UCI(s.pv[0][0])
+
+		// the principal variation is notated as each of its moves is made
+		res.PV = make([]string, 0, s.pvLen[0])
+		sts := make([]MoveState, 0, s.pvLen[0])
+		for _, m := range s.pv[0][:s.pvLen[0]] {
+			res.PV = append(res.PV, s.pos.UCI(m))
+			sts = append(sts, s.pos.MakeMove(m))
+		}
+		for i := len(sts) - 1; i >= 0; i-- {
+			s.pos.UnmakeMove(sts[i])
+		}
+
+		if s.report != nil {
+			info := *res
+			info.Nodes, info.Time = s.nodes, time.Since(start)
+			s.report(&info)
+		}
+
+		// a forced mate within the depth searched will not be improved upon
+		if res.Mate != 0 && abs(res.Mate)*2 <= d {
+			break
+		}
+	}
+
+	res.Nodes = s.nodes
+	res.Time = time.Since(start)
+
+	return res
+}
+
+// mateIn converts a score into the number of moves to mate, or zero.
+func mateIn(score int) int {
+	switch {
+	case score >= mateThreshold:
+		return (mateScore - score + 1) / 2
+	case score <= -mateThreshold:
+		return -(mateScore + score) / 2
+	default:
+		return 0
+	}
+}
+
+// abs returns the absolute value of n.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
+
+// stop reports whether the search must end, checking the limits of the search.
+func (s *searcher) stop() bool {
+	if s.stopped {
+		return true
+	}
+
+	if s.limits.Nodes > 0 && s.nodes >= s.limits.Nodes {
+		s.stopped = true
+	} else if s.nodes%searchCheckNodes == 0 {
+		s.stopped = s.ctx.Err() != nil || (!s.deadline.IsZero() && time.Now().After(s.deadline))
+	}
+
+	return s.stopped
+}
+
+// negamax searches the position to the given depth, returning its score from
+// the point of view of the side to move within the window of alpha and beta.
+func (s *searcher) negamax(depth, ply, alpha, beta int) int {
+	s.pvLen[ply] = 0
+	if s.stop() {
+		return 0
+	}
+	s.nodes++
+
+	p := s.pos
+	key := p.Hash()
+	if ply > 0 && s.isDraw(key) {
+		return 0
+	}
+
+	inCheck := p.InCheck()
+	if inCheck {
+		depth++
+	}
+
+	if depth <= 0 || ply >= maxSearchPly-1 {
+		return s.quiesce(ply, alpha, beta)
+	}
+
+	// the transposition table may hold the score of the position, or the best
+	// move of an earlier iteration
+	ent := &s.tt[key%searchTableSize]
+	var hashMove Move
+	if ent.key == key {
+		hashMove = ent.move
+
+		if ply > 0 && int(ent.depth) >= depth {
+			score := fromTableScore(int(ent.score), ply)
+			switch {
+			case ent.bound == boundExact,
+				ent.bound == boundLower && score >= beta,
+				ent.bound == boundUpper && score <= alpha:
+				return score
+			}
+		}
+	}
+
+	var buf [256]Move
+	var ord [256]int
+	mvs := p.LegalMoves(buf[:0])
+	if len(mvs) == 0 {
+		if inCheck {
+			return -mateScore + ply
+		}
+
+		return 0
+	}
+	s.order(mvs, ord[:len(mvs)], hashMove, ply)
+
+	s.keys = append(s.keys, key)
+	defer func() { s.keys = s.keys[:len(s.keys)-1] }()
+
+	best, bestMove, bound := -searchInfinity, Move(0), boundUpper
+	for i := range mvs {
+		m := pickMove(mvs, ord[:len(mvs)], i)
+		quiet := p.isQuiet(m)
+
+		st := p.MakeMove(m)
+
+		// moves after the first are searched with a null window, and searched
+		// again should they prove better than the principal variation
+		var score int
+		if i == 0 {
+			score = -s.negamax(depth-1, ply+1, -beta, -alpha)
+		} else {
+			score = -s.negamax(depth-1, ply+1, -alpha-1, -alpha)
+			if score > alpha && score < beta {
+				score = -s.negamax(depth-1, ply+1, -beta, -alpha)
+			}
+		}
+
+		p.UnmakeMove(st)
+
+		if s.stopped {
+			return 0
+		}
+
+		if score <= best {
+			continue
+		}
+		best, bestMove = score, m
+
+		if score <= alpha {
+			continue
+		}
+		alpha, bound = score, boundExact
+
+		s.pv[ply][0] = m
+		copy(s.pv[ply][1:], s.pv[ply+1][:s.pvLen[ply+1]])
+		s.pvLen[ply] = s.pvLen[ply+1] + 1
+
+		if alpha >= beta {
+			bound = boundLower
+			if quiet {
+				if s.killers[ply][0] != m {
+					s.killers[ply][1], s.killers[ply][0] = s.killers[ply][0], m
+				}
+				s.history[p.side][m.From()][m.To()] += depth * depth
+			}
+			break
+		}
+	}
+
+	*ent = ttEntry{
+		bound: uint8(bound),
+		depth: int8(depth),
+		key:   key,
+		move:  bestMove,
+		score: int32(toTableScore(best, ply)),
+	}
+
+	return best
+}
+
+// quiesce extends the search with captures and promotions until the position
+// is quiet, so that positions are not evaluated in the midst of an exchange.
+// A side in check cannot stand pat: every evasion is searched, and the side
+// is mated when it has none.
+func (s *searcher) quiesce(ply, alpha, beta int) int {
+	s.pvLen[ply] = 0
+	if s.stop() {
+		return 0
+	}
+	s.nodes++
+
+	p := s.pos
+	if ply >= maxSearchPly-1 {
+		return p.evaluate()
+	}
+
+	inCheck := p.InCheck()
+	if !inCheck {
+		stand := p.evaluate()
+		if stand >= beta {
+			return stand
+		}
+		alpha = max(alpha, stand)
+	}
+
+	var buf [256]Move
+	var ord [256]int
+	mvs := buf[:0]
+	for _, m := range p.LegalMoves(buf[:0]) {
+		// captures that lose material in the exchange are not worth searching,
+		// unless they are evasions
+		if inCheck || !p.isQuiet(m) && p.SEE(m) >= 0 {
+			mvs = append(mvs, m)
+		}
+	}
+
+	if inCheck && len(mvs) == 0 {
+		return -mateScore + ply
+	}
+	s.order(mvs, ord[:len(mvs)], 0, ply)
+
+	for i := range mvs {
+		m := pickMove(mvs, ord[:len(mvs)], i)
+
+		st := p.MakeMove(m)
+		score := -s.quiesce(ply+1, -beta, -alpha)
+		p.UnmakeMove(st)
+
+		if s.stopped {
+			return 0
+		}
+
+		if score >= beta {
+			return score
+		}
+		alpha = max(alpha, score)
+	}
+
+	return alpha
+}
+
+// isDraw reports whether the position is drawn by the fifty-move rule,
+// repetition or insufficient material.
+func (s *searcher) isDraw(key uint64) bool {
+	p := s.pos
+	if p.hmc >= 100 {
+		return true
+	}
+
+	// only positions since the last capture or pawn move can repeat
+	for i := len(s.keys) - 2; i >= 0 && i >= len(s.keys)-p.hmc; i -= 2 {
+		if s.keys[i] == key {
+			return true
+		}
+	}
+
+	return p.insufficientMaterial()
+}
+
+// insufficientMaterial reports whether neither side has the material to
+// checkmate: bare kings, or a king and a single minor piece against a king.
+func (p *Position) insufficientMaterial() bool {
+	for sd := range p.pieces {
+		pcs := &p.pieces[sd]
+		if pcs[piecePawn]|pcs[pieceRook]|pcs[pieceQueen] != 0 {
+			return false
+		}
+	}
+
+	minors := 0
+	for sd := range p.pieces {
+		minors += (p.pieces[sd][pieceBishop] | p.pieces[sd][pieceKnight]).count()
+	}
+
+	return minors <= 1
+}
+
+// isQuiet reports whether a move neither captures nor promotes.
+func (p *Position) isQuiet(m Move) bool {
+	if _, ok := m.Promotion(); ok {
+		return false
+	}
+
+	return m.flag() == moveFlagCastle || (m.flag() != moveFlagEnPassant && p.pcs[m.To()] == 0)
+}
+
+// order scores moves for the order in which they are searched: the move of
+// the transposition table, then captures of the most valuable pieces by the
+// least valuable, promotions, killer moves, quiet moves by their history and
+// finally captures that lose material by static exchange evaluation.
+func (s *searcher) order(mvs []Move, ord []int, hashMove Move, ply int) {
+	p := s.pos
+	for i, m := range mvs {
+		switch {
+		case m == hashMove:
+			ord[i] = hashMoveOrder
+		case !p.isQuiet(m):
+			if see := p.SEE(m); see < 0 {
+				ord[i] = losingCaptureOrder + see
+				continue
+			}
+
+			victim := piecePawn
+			if cpt := p.pcs[m.To()]; cpt != 0 && m.flag() != moveFlagCastle {
+				victim = cpt.kind()
+			}
+
+			ord[i] = captureOrder + materialValues[victim]*10 - materialValues[p.pcs[m.From()].kind()]/10
+			if pt, ok := m.Promotion(); ok {
+				ord[i] += materialValues[pt]
+			}
+		case m == s.killers[ply][0]:
+			ord[i] = killerOrder
+		case m == s.killers[ply][1]:
+			ord[i] = killerOrder - 1
+		default:
+			ord[i] = s.history[p.side][m.From()][m.To()]
+		}
+	}
+}
+
+// pickMove moves the highest ordered of the remaining moves into place i and
+// returns it, so that moves are only sorted as far as they are searched.
+func pickMove(mvs []Move, ord []int, i int) Move {
+	bst := i
+	for j := i + 1; j < len(mvs); j++ {
+		if ord[j] > ord[bst] {
+			bst = j
+		}
+	}
+
+	mvs[i], mvs[bst] = mvs[bst], mvs[i]
+	ord[i], ord[bst] = ord[bst], ord[i]
+
+	return mvs[i]
+}
+
+// toTableScore adjusts mate scores to be relative to the position stored,
+// rather than the root of the search.
+func toTableScore(score, ply int) int {
+	switch {
+	case score >= mateThreshold:
+		return score + ply
+	case score <= -mateThreshold:
+		return score - ply
+	default:
+		return score
+	}
+}
+
+// fromTableScore reverses toTableScore for a position at the given ply.
+func fromTableScore(score, ply int) int {
+	switch {
+	case score >= mateThreshold:
+		return score - ply
+	case score <= -mateThreshold:
+		return score + ply
+	default:
+		return score
+	}
+}
@@ -0,0 +1,184 @@
+package chess
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// mustSearch loads a FEN and searches it for the best move.
+func mustSearch(t *testing.T, fen string, limits SearchLimits) *SearchResult {
+	t.Helper()
+
+	client, err := CreateAlgebraicGameClientFromFEN(fen)
+	if err != nil {
+		t.Fatalf("failed to load FEN: %v", err)
+	}
+
+	res, err := client.BestMove(context.Background(), limits)
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+
+	if !slices.Contains(client.UCIMoves(), res.UCI) {
+		t.Fatalf("expected a legal move, got %s", res.UCI)
+	}
+
+	return res
+}
+
+func TestBestMoveFindsMate(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		mate int
+		move string
+	}{
+		{"back rank", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", 1, "Ra8#"},
+		{"scholar's mate", "r1bqkbnr/pppp1ppp/2n5/4p3/2B1P3/5Q2/PPPP1PPP/RNB1K1NR w KQkq - 0 1", 1, "Qxf7#"},
+		{"ladder", "7k/8/8/8/8/8/R7/1R4K1 w - - 0 1", 2, ""},
+		{"mated", "6k1/8/8/8/8/1r6/r7/6K1 w - - 0 1", -1, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res := mustSearch(t, tc.fen, SearchLimits{Depth: 6})
+			if res.Mate != tc.mate {
+				t.Fatalf("expected mate in %d, got %d (score %d)", tc.mate, res.Mate, res.Score)
+			}
+
+			if tc.move != "" && res.Move != tc.move {
+				t.Fatalf("expected %s, got %s", tc.move, res.Move)
+			}
+
+			if len(res.PV) == 0 || res.PV[0] != res.UCI {
+				t.Fatalf("expected the principal variation to begin with %s, got %v", res.UCI, res.PV)
+			}
+		})
+	}
+}
+
+func TestBestMoveWinsMaterial(t *testing.T) {
+	res := mustSearch(t, "4k3/8/8/3q4/8/8/3R4/4K3 w - - 0 1", SearchLimits{Depth: 4})
+	if res.Move != "Rxd5" || res.UCI != "d2d5" {
+		t.Fatalf("expected Rxd5 (d2d5), got %s (%s)", res.Move, res.UCI)
+	}
+
+	if res.Score < 400 {
+		t.Fatalf("expected a winning score, got %d", res.Score)
+	}
+}
+
+func TestBestMoveLimits(t *testing.T) {
+	fen := "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"
+
+	t.Run("depth", func(t *testing.T) {
+		if res := mustSearch(t, fen, SearchLimits{Depth: 3}); res.Depth != 3 {
+			t.Fatalf("expected depth 3, got %d", res.Depth)
+		}
+	})
+
+	t.Run("nodes", func(t *testing.T) {
+		if res := mustSearch(t, fen, SearchLimits{Nodes: 5000}); res.Nodes > 5000 {
+			t.Fatalf("expected at most 5000 nodes, got %d", res.Nodes)
+		}
+	})
+
+	t.Run("time", func(t *testing.T) {
+		res := mustSearch(t, fen, SearchLimits{Time: 50 * time.Millisecond})
+		if res.Time > time.Second {
+			t.Fatalf("expected the search to stop after 50ms, took %v", res.Time)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		client, err := CreateAlgebraicGameClientFromFEN(fen)
+		if err != nil {
+			t.Fatalf("failed to load FEN: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		res, err := client.BestMove(ctx, SearchLimits{})
+		if err != nil {
+			t.Fatalf("search failed: %v", err)
+		}
+
+		if !slices.Contains(client.UCIMoves(), res.UCI) || res.Move == "" {
+			t.Fatalf("expected a legal move, got %s (%s)", res.Move, res.UCI)
+		}
+	})
+}
+
+func TestBestMoveNoLegalMoves(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN("7k/6Q1/6K1/8/8/8/8/8 b - - 0 1")
+	if err != nil {
+		t.Fatalf("failed to load FEN: %v", err)
+	}
+
+	if _, err := client.BestMove(context.Background(), SearchLimits{Depth: 1}); err == nil {
+		t.Fatalf("expected an error for a checkmated position")
+	}
+}
+
+func TestBestMoveRepetition(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN("4k3/8/8/8/8/8/8/R3K3 w - - 0 1")
+	if err != nil {
+		t.Fatalf("failed to load FEN: %v", err)
+	}
+
+	for _, ntn := range []string{"Ra2", "Kd8", "Ra1", "Ke8", "Ra2"} {
+		mustMove(t, client, ntn)
+	}
+
+	// the lone king draws by returning to a position of the game
+	res, err := client.BestMove(context.Background(), SearchLimits{Depth: 2})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+
+	if res.Move != "Kd8" || res.Score != 0 {
+		t.Fatalf("expected Kd8 with a drawn score, got %s (%d)", res.Move, res.Score)
+	}
+}
+
+func BenchmarkBestMove(b *testing.B) {
+	client, err := CreateAlgebraicGameClientFromFEN(generatorPositions[1].fen)
+	if err != nil {
+		b.Fatalf("failed to load FEN: %v", err)
+	}
+
+	for b.Loop() {
+		if _, err := client.BestMove(context.Background(), SearchLimits{Depth: 5}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestQuiesceInCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		want func(score int) bool
+	}{
+		// a smothered mate is not stood pat on
+		{"mated", "6rk/5Npp/8/8/8/8/8/Q5K1 b - - 0 1", func(score int) bool { return score == -mateScore }},
+		// the only evasions are quiet moves, after which the knight forks the queen
+		{"fork", "4k3/8/8/8/8/8/2n5/K3Q3 w - - 0 1", func(score int) bool { return score < 0 }},
+		// the only evasion is a capture that loses the queen in the exchange
+		{"losing capture", "4k3/8/8/8/2p5/1n6/RP6/KB1Q4 w - - 0 1", func(score int) bool { return score > -mateThreshold }},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := mustFEN(t, tc.fen)
+			s := newSearcher(context.Background(), newPosition(client.game), nil, SearchLimits{})
+
+			if score := s.quiesce(0, -searchInfinity, searchInfinity); !tc.want(score) {
+				t.Fatalf("unexpected score %d", score)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package chess
+
+import "fmt"
+
+// seeKingValue is the value of the king in an exchange: more than every other
+// piece together, so that the king only captures pieces left undefended.
+const seeKingValue = 10000
+
+// seeAttackerOrder lists the pieces from least to most valuable, the order in
+// which they join an exchange.
+var seeAttackerOrder = [6]pieceType{piecePawn, pieceKnight, pieceBishop, pieceRook, pieceQueen, pieceKing}
+
+// seeValue returns the value of a piece in an exchange.
+func seeValue(pt pieceType) int {
+	if pt == pieceKing {
+		return seeKingValue
+	}
+
+	return materialValues[pt]
+}
+
+// SEE returns the static exchange evaluation of a move: the material in
+// centipawns the side to move gains once the move is answered by the best
+// sequence of captures on its destination square, where either side may stop
+// capturing. Attackers behind the pieces that capture (x-rays) join the
+// exchange, while pins are ignored. A quiet move scores zero unless the
+// moving piece can be won; castling always scores zero.
+func (p *Position) SEE(m Move) int {
+	if m.flag() == moveFlagCastle {
+		return 0
+	}
+
+	from, to := m.From(), m.To()
+	occ := p.occupied() &^ squareBit(from)
+
+	gain := 0
+	if cpt := p.pcs[to]; cpt != 0 {
+		gain = seeValue(cpt.kind())
+	}
+
+	if m.flag() == moveFlagEnPassant {
+		gain = materialValues[piecePawn]
+		occ &^= squareBit(p.enPassantCaptureSquare(to))
+	}
+
+	val := seeValue(p.pcs[from].kind())
+	if pt, ok := m.Promotion(); ok {
+		gain += materialValues[pt] - materialValues[piecePawn]
+		val = materialValues[pt]
+	}
+
+	return gain - p.exchange(to, val, occ, p.side.Opponent())
+}
+
+// SEESquare returns the material in centipawns the opponent of the piece on
+// sq gains by starting an exchange on the square, regardless of the side to
+// move. It is zero when the square is empty, or when the piece is defended
+// well enough that no capture wins material; a positive value means the
+// piece is hanging.
+func (p *Position) SEESquare(sq int) int {
+	pc := p.pcs[sq]
+	if pc == 0 {
+		return 0
+	}
+
+	return p.exchange(sq, seeValue(pc.kind()), p.occupied(), pc.side().Opponent())
+}
+
+// exchange returns the material the given side gains by capturing on sq, where
+// the piece on it is worth val, after which each side recaptures with its
+// least valuable attacker. Either side stops once capturing loses material.
+// Pieces no longer in occ have already captured, revealing those behind them.
+func (p *Position) exchange(sq int, val int, occ bitboard, sd Side) int {
+	var gains [32]int
+	n := 0
+	for ; n < len(gains); n++ {
+		from, pt := p.leastValuableAttacker(sq, occ, sd)
+		if from < 0 {
+			break
+		}
+
+		gains[n] = val
+		val = seeValue(pt)
+		if pt == piecePawn && (rank1 | rank8).has(sq) {
+			gains[n] += materialValues[pieceQueen] - materialValues[piecePawn]
+			val = materialValues[pieceQueen]
+		}
+
+		occ &^= squareBit(from)
+		sd = sd.Opponent()
+	}
+
+	// each capture is only made when it gains more than the exchange that follows it
+	score := 0
+	for n--; n >= 0; n-- {
+		score = max(0, gains[n]-score)
+	}
+
+	return score
+}
+
+// leastValuableAttacker returns the square and type of the least valuable
+// piece of the given side in occ attacking sq, or -1 when there is none.
+func (p *Position) leastValuableAttacker(sq int, occ bitboard, sd Side) (int, pieceType) {
+	att := p.attackersTo(sq, occ, sd) & occ
+	if att == 0 {
+		return -1, 0
+	}
+
+	for _, pt := range seeAttackerOrder {
+		if bb := att & p.pieces[sd][pt]; bb != 0 {
+			return bb.first(), pt
+		}
+	}
+
+	return -1, 0
+}
+
+// SEE returns the static exchange evaluation of a legal move in algebraic
+// notation (e.g. "Nxe5"): the material in centipawns the side to move gains
+// once the best sequence of recaptures on the destination square has been
+// played out, including x-ray attackers.
+func (c *AlgebraicGameClient) SEE(ntn string) (int, error) {
+	uci, err := c.SANToUCI(ntn)
+	if err != nil {
+		return 0, err
+	}
+
+	pos := newPosition(c.game)
+	for _, m := range pos.LegalMoves(nil) {
+		if pos.UCI(m) == uci {
+			return pos.SEE(m), nil
+		}
+	}
+
+	return 0, fmt.Errorf("notation is invalid (%s)", ntn)
+}
+
+// SEESquare returns the material in centipawns the opponent of the piece on
+// the named square (e.g. "e5") gains by starting an exchange on it, or zero
+// when no capture wins material. See Position.SEESquare.
+func (c *AlgebraicGameClient) SEESquare(name string) (int, error) {
+	sq := c.game.Board.getSquareByName(name)
+	if sq == nil {
+		return 0, fmt.Errorf("square is invalid (%s)", name)
+	}
+
+	return newPosition(c.game).SEESquare(c.game.Board.indexOf(sq)), nil
+}
+
+// HangingPieces returns the squares of the pieces of the given side, other
+// than the king, that the opponent wins material by capturing according to
+// static exchange evaluation.
+func (c *AlgebraicGameClient) HangingPieces(sd Side) []*Square {
+	pos := newPosition(c.game)
+
+	var sqs []*Square
+	for pcs := pos.occ[sd] &^ pos.pieces[sd][pieceKing]; pcs != 0; {
+		if sq := pcs.pop(); pos.SEESquare(sq) > 0 {
+			sqs = append(sqs, c.game.Board.Squares[sq])
+		}
+	}
+
+	return sqs
+}
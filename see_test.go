@@ -0,0 +1,84 @@
+package chess
+
+import "testing"
+
+func TestSEE(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		ntn  string
+		want int
+	}{
+		{"undefended pawn", "4k3/8/8/3p4/8/8/8/3QK3 w - - 0 1", "Qxd5", 100},
+		{"defended pawn", "4k3/4p3/3p4/8/8/8/8/3QK3 w - - 0 1", "Qxd6", -800},
+		{"pawn takes defended knight", "4k3/4p3/3n4/2P5/8/8/8/4K3 w - - 0 1", "cxd6", 220},
+		{"rooks doubled behind", "3r2k1/8/8/3p4/8/8/3R4/3RK3 w - - 0 1", "Rxd5", 100},
+		{"queen behind bishop", "4k3/8/5p2/4p3/8/8/1B6/Q3K3 w - - 0 1", "Bxe5", -130},
+		{"en passant", "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "exd6", 100},
+		{"promotion recaptured", "1r2k3/P7/8/8/8/8/8/4K3 w - - 0 1", "a8=Q", -100},
+		{"capturing promotion", "1r2k3/P7/8/8/8/8/8/4K3 w - - 0 1", "axb8=Q", 1300},
+		{"quiet move to an attacked square", "4k3/8/8/4p3/8/8/8/3QK3 w - - 0 1", "Qd4", -900},
+		{"castling", "4k3/8/8/8/8/8/8/R3K2R w KQ - 0 1", "O-O", 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := mustFEN(t, tc.fen).SEE(tc.ntn)
+			if err != nil {
+				t.Fatalf("failed to evaluate %s: %v", tc.ntn, err)
+			}
+
+			if got != tc.want {
+				t.Fatalf("expected %s to score %d, got %d", tc.ntn, tc.want, got)
+			}
+		})
+	}
+
+	if _, err := CreateAlgebraicGameClient().SEE("Qxh7"); err == nil {
+		t.Fatalf("expected an error for an illegal move")
+	}
+}
+
+func TestSEESquare(t *testing.T) {
+	// the rook on h4 is attacked by the bishop and undefended, while the
+	// knight on c6 is attacked by the rook and defended by a pawn
+	client := mustFEN(t, "4k3/3p4/2n5/8/7r/8/5B2/2R1K3 w - - 0 1")
+
+	tests := map[string]int{
+		"e4": 0,
+		"h4": 500,
+		"c6": 0,
+		"d7": 0,
+		"f2": 0,
+	}
+
+	for name, want := range tests {
+		got, err := client.SEESquare(name)
+		if err != nil {
+			t.Fatalf("failed to evaluate %s: %v", name, err)
+		}
+
+		if got != want {
+			t.Fatalf("expected %s to score %d, got %d", name, want, got)
+		}
+	}
+
+	if _, err := client.SEESquare("z9"); err == nil {
+		t.Fatalf("expected an error for an invalid square")
+	}
+}
+
+func TestHangingPieces(t *testing.T) {
+	// the bishop on d5 is attacked by the rook and the knight and undefended,
+	// while the knights on e6 and f4 attack each other and are both defended
+	client := mustFEN(t, "4k3/5p2/4n3/3b4/5N2/6P1/8/3RK3 w - - 0 1")
+
+	sqs := client.HangingPieces(Black)
+	if len(sqs) != 1 || sqs[0].name() != "d5" {
+		t.Fatalf("expected d5 to be hanging, got %v", sqs)
+	}
+
+	if sqs := client.HangingPieces(White); len(sqs) != 0 {
+		t.Fatalf("expected no hanging white pieces, got %v", sqs)
+	}
+}
@@ -10,6 +10,13 @@ const (
 	sideBlack
 )
 
+const (
+	// White identifies the white side in the public API.
+	White = sideWhite
+	// Black identifies the black side in the public API.
+	Black = sideBlack
+)
+
 // Name returns the string representation of the side ("white" or "black").
 func (s Side) Name() string {
 	if s == sideWhite {
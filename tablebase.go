@@ -0,0 +1,672 @@
+package chess
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	tbIllegal   int16 = math.MinInt16 // tbIllegal marks an index that is not a legal, canonical position.
+	tbMaxPieces       = 4             // tbMaxPieces is the largest number of pieces, kings included, a table may hold.
+	tbPieces          = "KQRBNP"      // tbPieces are the letters of the pieces in the order they are listed in a material signature.
+)
+
+// ErrNotInTablebase is returned when a position cannot be solved by the tablebase.
+var ErrNotInTablebase = errors.New("tablebase: position is not in the tablebase")
+
+// tbPieceTypes are the types of the pieces of tbPieces.
+var tbPieceTypes = [6]pieceType{pieceKing, pieceQueen, pieceRook, pieceBishop, pieceKnight, piecePawn}
+
+// tbSymmetries maps each square to its image under the symmetries of the
+// board: mirroring the files (1), the ranks (2) and the a1-h8 diagonal (4).
+// Tables with pawns only use the first two, where the ranks are not mirrored.
+var tbSymmetries [8][64]int
+
+func init() {
+	for sym := range tbSymmetries {
+		for sq := range 64 {
+			f, r := sq%8, sq/8
+			if sym&1 != 0 {
+				f = 7 - f
+			}
+
+			if sym&2 != 0 {
+				r = 7 - r
+			}
+
+			if sym&4 != 0 {
+				f, r = r, f
+			}
+
+			tbSymmetries[sym][sq] = r*8 + f
+		}
+	}
+}
+
+// TablebaseOptions configures an endgame tablebase.
+type TablebaseOptions struct {
+	Dir string // Dir is a directory in which solved tables are saved and from which they are loaded (in memory only when empty).
+}
+
+// TablebaseResult is the value of a position with perfect play.
+type TablebaseResult struct {
+	DTM    int    // DTM is the distance to mate in plies (zero for draws and for positions that are checkmate).
+	Move   string // Move is a best move in Standard Algebraic Notation (empty when there are no legal moves).
+	Result Result // Result is the result of the game with perfect play.
+	UCI    string // UCI is the best move in UCI notation.
+}
+
+// tbTable holds the solved values of every position of a material signature,
+// where white has the first side's pieces (e.g. the rook of "KRKP"). Values
+// are from the point of view of the side to move: plies+1 when it mates in
+// the given number of plies, -(plies+1) when it is mated and zero for draws.
+type tbTable struct {
+	codes   []pieceCode // codes are the pieces of the signature, in index order; the white king is first.
+	dtm     []int16
+	region  [64]int // region numbers the squares the white king is restricted to by symmetry, or -1.
+	size    int     // size is the number of indices for each side to move.
+	squares []int   // squares are the squares of the region, by number.
+	syms    int     // syms is the number of symmetries of the table.
+}
+
+// tablebase solves endgames of up to four pieces by retrograde analysis,
+// building exact win, draw and loss values with distances to mate. Tables are
+// solved when first needed, along with the tables reached by captures and
+// promotions. It is safe for concurrent use.
+type tablebase struct {
+	mu     sync.Mutex
+	opts   TablebaseOptions
+	tables map[string]*tbTable
+}
+
+// CreateTablebase creates an endgame tablebase. Tables are held in memory
+// and, when a directory is provided, saved to it once solved so that they
+// are loaded rather than solved again.
+func CreateTablebase(opts ...TablebaseOptions) *tablebase {
+	var o TablebaseOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	return &tablebase{opts: o, tables: map[string]*tbTable{}}
+}
+
+// Solve solves the table of a material signature, listing the pieces of one
+// side, king first, then those of the other (e.g. "KPK", "KBNK" or "KRKP").
+// Tables are solved when first probed, so Solve only needs to be called to
+// prepare them in advance. Signatures of more than four pieces, and those
+// with pawns for both sides (whose en passant rights are not part of a
+// table), are not supported.
+func (tb *tablebase) Solve(material string) error {
+	sig, err := parseMaterial(material)
+	if err != nil {
+		return err
+	}
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	_, err = tb.table(sig)
+	return err
+}
+
+// Probe returns the value of the current position of the client with perfect
+// play, along with a best move: the fastest mate when winning, a drawing move
+// when drawn and the longest resistance when losing. Distances to mate ignore
+// the fifty-move rule. ErrNotInTablebase is returned for positions of more
+// than four pieces, with castling rights or with pawns for both sides.
+func (tb *tablebase) Probe(c *AlgebraicGameClient) (*TablebaseResult, error) {
+	p := newPosition(c.game)
+	if p.cstl != (castleRights{}) {
+		return nil, ErrNotInTablebase
+	}
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	v, err := tb.value(p)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &TablebaseResult{Result: ResultDraw}
+	switch {
+	case v > 0:
+		res.DTM, res.Result = int(v)-1, winFor(p.side)
+	case v < 0:
+		res.DTM, res.Result = int(-v)-1, winFor(p.side.Opponent())
+	}
+
+	var buf [256]Move
+	best, bs := Move(0), 0
+	for i, m := range p.LegalMoves(buf[:0]) {
+		st := p.MakeMove(m)
+		v, err := tb.value(p)
+		p.UnmakeMove(st)
+		if err != nil {
+			return nil, err
+		}
+
+		if s := tbScore(v); i == 0 || s > bs {
+			best, bs = m, s
+		}
+	}
+
+	if best != 0 {
+		res.UCI = p.UCI(best)
+		san, err := c.UCIToSAN(res.UCI)
+		if err != nil {
+			return nil, err
+		}
+		res.Move = san
+	}
+
+	return res, nil
+}
+
+// tbScore scores a move by the value of the position after it, which is
+// from the point of view of the opponent: mating sooner scores higher than
+// mating later, which scores higher than a draw, then being mated later.
+func tbScore(v int16) int {
+	switch {
+	case v < 0:
+		return math.MaxInt16 + int(v)
+	case v > 0:
+		return math.MinInt16 + int(v)
+	default:
+		return 0
+	}
+}
+
+// parseMaterial returns the signature of a material description, listing the
+// stronger side first.
+func parseMaterial(material string) (string, error) {
+	s := strings.ToUpper(material)
+	i := strings.Index(s[min(1, len(s)):], "K") + 1
+	if !strings.HasPrefix(s, "K") || i == 0 {
+		return "", fmt.Errorf("tablebase: invalid material %q", material)
+	}
+
+	w, b := []byte(s[:i]), []byte(s[i:])
+	for _, side := range [][]byte{w[1:], b[1:]} {
+		if strings.ContainsAny(string(side), "K") || strings.Trim(string(side), tbPieces) != "" {
+			return "", fmt.Errorf("tablebase: invalid material %q", material)
+		}
+
+		slices.SortFunc(side, func(x, y byte) int {
+			return strings.IndexByte(tbPieces, x) - strings.IndexByte(tbPieces, y)
+		})
+	}
+
+	sig, _ := tbSignature(string(w), string(b))
+	return sig, nil
+}
+
+// tbSignature returns the signature of the material of both sides, listing
+// the stronger first, and whether that is black.
+func tbSignature(w, b string) (string, bool) {
+	if len(b) > len(w) || len(b) == len(w) && tbStronger(b, w) {
+		return b + w, true
+	}
+
+	return w + b, false
+}
+
+// tbStronger reports whether the pieces of a are stronger than those of b,
+// comparing piece by piece in signature order.
+func tbStronger(a, b string) bool {
+	for i := range min(len(a), len(b)) {
+		if a[i] != b[i] {
+			return strings.IndexByte(tbPieces, a[i]) < strings.IndexByte(tbPieces, b[i])
+		}
+	}
+
+	return false
+}
+
+// material returns the pieces of a side of the position, king first.
+func (p *Position) material(sd Side) string {
+	var sb strings.Builder
+	for i, pt := range tbPieceTypes {
+		sb.WriteString(strings.Repeat(tbPieces[i:i+1], p.pieces[sd][pt].count()))
+	}
+
+	return sb.String()
+}
+
+// value returns the value of the position from its table, solving the table
+// when it is first needed.
+func (tb *tablebase) value(p *Position) (int16, error) {
+	w, b := p.material(sideWhite), p.material(sideBlack)
+	if w == "K" && b == "K" {
+		return 0, nil
+	}
+
+	sig, flip := tbSignature(w, b)
+	t, err := tb.table(sig)
+	if err != nil {
+		return 0, err
+	}
+
+	v := t.dtm[t.index(p, flip)]
+	if v == tbIllegal {
+		return 0, ErrNotInTablebase
+	}
+
+	return v, nil
+}
+
+// table returns the table of a signature, loading or solving it when it is
+// first needed.
+func (tb *tablebase) table(sig string) (*tbTable, error) {
+	if t, ok := tb.tables[sig]; ok {
+		return t, nil
+	}
+
+	t, err := newTable(sig)
+	if err != nil {
+		return nil, err
+	}
+
+	fn := ""
+	if tb.opts.Dir != "" {
+		fn = filepath.Join(tb.opts.Dir, sig+".tbl")
+		if err := t.load(fn); err == nil {
+			tb.tables[sig] = t
+			return t, nil
+		}
+	}
+
+	if err := tb.solve(t); err != nil {
+		return nil, err
+	}
+
+	if fn != "" {
+		if err := t.save(fn); err != nil {
+			return nil, err
+		}
+	}
+
+	tb.tables[sig] = t
+	return t, nil
+}
+
+// newTable creates the unsolved table of a signature.
+func newTable(sig string) (*tbTable, error) {
+	i := strings.LastIndexByte(sig, 'K')
+	if len(sig) > tbMaxPieces || strings.Contains(sig[:i], "P") && strings.Contains(sig[i:], "P") {
+		return nil, ErrNotInTablebase
+	}
+
+	t := &tbTable{syms: 8}
+	if strings.Contains(sig, "P") {
+		t.syms = 2
+	}
+
+	for n, ch := range []byte(sig) {
+		sd := sideWhite
+		if n >= i {
+			sd = sideBlack
+		}
+		t.codes = append(t.codes, codeOf(tbPieceTypes[strings.IndexByte(tbPieces, ch)], sd))
+	}
+
+	// the white king is restricted to the a1-d1-d4 triangle, or to the a-d
+	// files when pawns break the symmetry of the ranks and the diagonal
+	for sq := range 64 {
+		t.region[sq] = -1
+		if f, r := sq%8, sq/8; f <= 3 && (t.syms == 2 || r <= f) {
+			t.region[sq] = len(t.squares)
+			t.squares = append(t.squares, sq)
+		}
+	}
+
+	t.size = len(t.squares)
+	for range t.codes[1:] {
+		t.size *= 64
+	}
+	t.dtm = make([]int16, 2*t.size)
+
+	return t, nil
+}
+
+// index returns the index of the position, whose pieces must be those of the
+// table (with colours reversed when flip is true).
+func (t *tbTable) index(p *Position, flip bool) int {
+	var sqs [tbMaxPieces]int
+	var used bitboard
+	for i, pc := range t.codes {
+		sd, pt := pc.side(), pc.kind()
+		if flip {
+			sd = sd.Opponent()
+		}
+
+		// pieces of the same type take the squares in turn
+		sq := (p.pieces[sd][pt] &^ used).first()
+		used |= squareBit(sq)
+		if flip {
+			sq ^= 56
+		}
+		sqs[i] = sq
+	}
+
+	stm := p.side
+	if flip {
+		stm = stm.Opponent()
+	}
+
+	return t.canonical(sqs, stm)
+}
+
+// canonical returns the index of the position with pieces on the given
+// squares, in the order of the table's pieces. Of the symmetric positions,
+// that of the lowest index is used, and pieces of the same type are ordered
+// by square so that each position has a single index.
+func (t *tbTable) canonical(sqs [tbMaxPieces]int, stm Side) int {
+	best := -1
+	for sym := range t.syms {
+		r := t.region[tbSymmetries[sym][sqs[0]]]
+		if r < 0 {
+			continue
+		}
+
+		var img [tbMaxPieces]int
+		for i, sq := range sqs[:len(t.codes)] {
+			img[i] = tbSymmetries[sym][sq]
+			for j := i; j > 1 && t.codes[j-1] == t.codes[j] && img[j-1] > img[j]; j-- {
+				img[j-1], img[j] = img[j], img[j-1]
+			}
+		}
+
+		idx := int(stm)*len(t.squares) + r
+		for _, sq := range img[1:len(t.codes)] {
+			idx = idx*64 + sq
+		}
+
+		if best < 0 || idx < best {
+			best = idx
+		}
+	}
+
+	return best
+}
+
+// squaresOf returns the squares of the pieces of an index, in the order of
+// the table's pieces, and the side to move.
+func (t *tbTable) squaresOf(idx int) ([tbMaxPieces]int, Side) {
+	stm := Side(idx / t.size)
+
+	idx %= t.size
+	var sqs [tbMaxPieces]int
+	for i := len(t.codes) - 1; i > 0; i-- {
+		sqs[i] = idx % 64
+		idx /= 64
+	}
+	sqs[0] = t.squares[idx]
+
+	return sqs, stm
+}
+
+// setup places the pieces of an index on an empty position, returning false
+// when pieces share a square or a pawn stands on the first or eighth rank.
+func (t *tbTable) setup(p *Position, idx int) bool {
+	sqs, stm := t.squaresOf(idx)
+	p.side = stm
+
+	for i, pc := range t.codes {
+		sq := sqs[i]
+		if p.pcs[sq] != 0 || pc.kind() == piecePawn && (rank1|rank8).has(sq) {
+			return false
+		}
+		p.put(sq, pc)
+	}
+
+	return true
+}
+
+// clear removes every piece from the position.
+func (p *Position) clear() {
+	for occ := p.occupied(); occ != 0; {
+		p.remove(occ.pop())
+	}
+}
+
+// solve solves the table by retrograde analysis. Positions are resolved in
+// order of their distance to mate: mates first, then the positions a ply
+// further away, and so on. A predecessor of a loss is a win a ply longer,
+// while a predecessor of a win is a loss once every one of its moves is
+// known to lose. Captures and promotions are valued from the tables they
+// lead to, so a position may first be resolved through them.
+func (tb *tablebase) solve(t *tbTable) error {
+	var levels [][]int32
+	queued := make([]int16, len(t.dtm)) // queued is the ply+1 a position was last queued to be evaluated at.
+	push := func(plies, idx int) {
+		for len(levels) <= plies {
+			levels = append(levels, nil)
+		}
+		levels[plies] = append(levels[plies], int32(idx))
+	}
+
+	p := &Position{enP: -1}
+	for idx := range t.dtm {
+		p.clear()
+		if !t.setup(p, idx) || t.index(p, false) != idx ||
+			p.attackersTo(p.kingSquare(p.side.Opponent()), p.occupied(), p.side) != 0 {
+			t.dtm[idx] = tbIllegal
+			continue
+		}
+
+		v, err := tb.evaluate(t, p, false)
+		if err != nil {
+			return err
+		}
+
+		if v != 0 {
+			push(abs(int(v))-1, idx)
+		}
+	}
+
+	for plies := 0; plies < len(levels); plies++ {
+		for _, idx := range levels[plies] {
+			idx := int(idx)
+			if t.dtm[idx] != 0 && abs(int(t.dtm[idx]))-1 != plies {
+				continue
+			}
+
+			if t.dtm[idx] == 0 {
+				p.clear()
+				t.setup(p, idx)
+
+				v, err := tb.evaluate(t, p, true)
+				if err != nil {
+					return err
+				}
+
+				if v == 0 || abs(int(v))-1 < plies {
+					continue
+				}
+
+				if abs(int(v))-1 > plies {
+					push(abs(int(v))-1, idx)
+					continue
+				}
+				t.dtm[idx] = v
+			}
+
+			win := t.dtm[idx] > 0
+			t.predecessors(idx, func(pred int) {
+				if t.dtm[pred] != 0 {
+					return
+				}
+
+				if win {
+					// the opponent loses once every move is known to lose
+					if queued[pred] != int16(plies+2) {
+						queued[pred] = int16(plies + 2)
+						push(plies+1, pred)
+					}
+					return
+				}
+
+				t.dtm[pred] = int16(plies + 2)
+				push(plies+1, pred)
+			})
+		}
+		levels[plies] = nil
+	}
+
+	return nil
+}
+
+// evaluate returns the value of the position from the values of the
+// positions after each legal move, or zero when it is not yet known. Moves
+// that leave the table (captures and promotions) are valued first, and those
+// within it only when intra is true. A position is only evaluated once any
+// move within the table to a lost position has made it a win, so the moves
+// within it can only show that it is lost.
+func (tb *tablebase) evaluate(t *tbTable, p *Position, intra bool) (int16, error) {
+	var buf [256]Move
+	mvs := p.LegalMoves(buf[:0])
+	if len(mvs) == 0 {
+		if p.InCheck() {
+			return -1, nil
+		}
+
+		return 0, nil
+	}
+
+	win, loss, inside, drawn := 0, 0, 0, false
+	for _, m := range mvs {
+		if _, promo := m.Promotion(); p.pcs[m.To()] == 0 && !promo {
+			inside++
+			continue
+		}
+
+		st := p.MakeMove(m)
+		v, err := tb.value(p)
+		p.UnmakeMove(st)
+		if err != nil {
+			return 0, err
+		}
+
+		switch {
+		case v < 0:
+			// mating a ply after the opponent is mated
+			if plies := int(-v); win == 0 || plies < win {
+				win = plies
+			}
+		case v > 0:
+			loss = max(loss, int(v))
+		default:
+			drawn = true
+		}
+	}
+
+	if win > 0 {
+		return int16(win + 1), nil
+	}
+
+	if drawn || inside > 0 && !intra {
+		return 0, nil
+	}
+
+	for _, m := range mvs {
+		if _, promo := m.Promotion(); p.pcs[m.To()] != 0 || promo {
+			continue
+		}
+
+		st := p.MakeMove(m)
+		v := t.dtm[t.index(p, false)]
+		p.UnmakeMove(st)
+		if v <= 0 {
+			return 0, nil
+		}
+		loss = max(loss, int(v))
+	}
+
+	return int16(-loss - 1), nil
+}
+
+// predecessors calls fn with the index of every legal position from which a
+// move of the side that is not to move, neither a capture nor a promotion,
+// reaches the position of an index.
+func (t *tbTable) predecessors(idx int, fn func(int)) {
+	sqs, stm := t.squaresOf(idx)
+	us := stm.Opponent()
+
+	var occ bitboard
+	for _, sq := range sqs[:len(t.codes)] {
+		occ |= squareBit(sq)
+	}
+
+	for i, pc := range t.codes {
+		if pc.side() != us {
+			continue
+		}
+
+		sq := sqs[i]
+		var from bitboard
+		switch pt := pc.kind(); pt {
+		case piecePawn:
+			// pawns move back a square, or two from the fourth rank
+			back, r := -8, sq/8
+			if us == sideBlack {
+				back, r = 8, 7-r
+			}
+
+			if r >= 2 && !occ.has(sq+back) {
+				from |= squareBit(sq + back)
+				if r == 3 && !occ.has(sq+2*back) {
+					from |= squareBit(sq + 2*back)
+				}
+			}
+		case pieceKing:
+			from = kingAttacks[sq] &^ occ
+		default:
+			from = pieceAttacks(pt, sq, occ) &^ occ
+		}
+
+		pred := sqs
+		for from != 0 {
+			pred[i] = from.pop()
+			if pi := t.canonical(pred, us); t.dtm[pi] != tbIllegal {
+				fn(pi)
+			}
+		}
+	}
+}
+
+// load reads the table from a file saved by save.
+func (t *tbTable) load(fn string) error {
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		return err
+	}
+
+	if len(b) != 2*len(t.dtm) {
+		return fmt.Errorf("tablebase: %s is not a table of %d positions", fn, len(t.dtm))
+	}
+
+	for i := range t.dtm {
+		t.dtm[i] = int16(binary.LittleEndian.Uint16(b[2*i:]))
+	}
+
+	return nil
+}
+
+// save writes the table to a file.
+func (t *tbTable) save(fn string) error {
+	b := make([]byte, 0, 2*len(t.dtm))
+	for _, v := range t.dtm {
+		b = binary.LittleEndian.AppendUint16(b, uint16(v))
+	}
+
+	return os.WriteFile(fn, b, 0o644)
+}
@@ -0,0 +1,263 @@
+package chess
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// mustProbe loads a FEN and probes it.
+func mustProbe(t *testing.T, tb *tablebase, fen string) *TablebaseResult {
+	t.Helper()
+
+	res, err := tb.Probe(mustFEN(t, fen))
+	if err != nil {
+		t.Fatalf("failed to probe %s: %v", fen, err)
+	}
+
+	return res
+}
+
+func TestTablebaseLongestMates(t *testing.T) {
+	tb := CreateTablebase()
+
+	// the longest mates with white to move, in plies
+	tests := []struct {
+		material string
+		plies    int
+	}{
+		{"KQK", 19},
+		{"KRK", 31},
+		{"KPK", 55},
+	}
+
+	for _, tc := range tests {
+		if err := tb.Solve(tc.material); err != nil {
+			t.Fatalf("failed to solve %s: %v", tc.material, err)
+		}
+
+		tbl, longest := tb.tables[tc.material], 0
+		for _, v := range tbl.dtm[:tbl.size] {
+			longest = max(longest, int(v)-1)
+		}
+
+		if longest != tc.plies {
+			t.Fatalf("expected the longest mate of %s to be %d plies, got %d", tc.material, tc.plies, longest)
+		}
+	}
+}
+
+func TestTablebaseProbe(t *testing.T) {
+	tb := CreateTablebase()
+
+	tests := []struct {
+		name   string
+		fen    string
+		result Result
+		dtm    int
+		move   string
+	}{
+		{"mate in one", "7k/8/6K1/8/8/8/8/1R6 w - - 0 1", ResultWhiteWins, 1, "Rb8#"},
+		{"checkmate", "R5k1/8/6K1/8/8/8/8/8 b - - 0 1", ResultWhiteWins, 0, ""},
+		{"stalemate", "k7/8/1QK5/8/8/8/8/8 b - - 0 1", ResultDraw, 0, ""},
+		{"hanging rook", "8/8/8/8/8/2k5/1R6/7K b - - 0 1", ResultDraw, 0, "Kxb2"},
+		{"rook's pawn", "k7/8/K7/P7/8/8/8/8 w - - 0 1", ResultDraw, 0, ""},
+		{"king ahead of the pawn", "4k3/8/4K3/4P3/8/8/8/8 b - - 0 1", ResultWhiteWins, 0, ""},
+		{"black queen", "k7/8/8/8/8/3q4/8/7K w - - 0 1", ResultBlackWins, 0, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res := mustProbe(t, tb, tc.fen)
+			if res.Result != tc.result {
+				t.Fatalf("expected %s, got %s", tc.result, res.Result)
+			}
+
+			if tc.dtm > 0 && res.DTM != tc.dtm {
+				t.Fatalf("expected mate in %d plies, got %d", tc.dtm, res.DTM)
+			}
+
+			if tc.move != "" && res.Move != tc.move {
+				t.Fatalf("expected %s, got %s", tc.move, res.Move)
+			}
+		})
+	}
+}
+
+func TestTablebaseFourPieces(t *testing.T) {
+	// solving the tables takes tens of seconds, so they are only solved on request
+	if os.Getenv("CHESS_TABLEBASE_FOUR_PIECES") != "1" {
+		t.Skip("set CHESS_TABLEBASE_FOUR_PIECES=1 to solve the four-piece tables")
+	}
+
+	tb := CreateTablebase()
+
+	// the longest mates with white to move, in plies
+	for material, plies := range map[string]int{"KQQK": 7, "KRRK": 13, "KBBK": 37, "KNNK": 1, "KBNK": 65} {
+		if err := tb.Solve(material); err != nil {
+			t.Fatalf("failed to solve %s: %v", material, err)
+		}
+
+		tbl, longest := tb.tables[material], 0
+		for _, v := range tbl.dtm[:tbl.size] {
+			longest = max(longest, int(v)-1)
+		}
+
+		if longest != plies {
+			t.Fatalf("expected the longest mate of %s to be %d plies, got %d", material, plies, longest)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		fen    string
+		result Result
+		dtm    int
+		move   string
+	}{
+		{"two rooks", "8/8/8/4k3/8/8/8/R5RK w - - 0 1", ResultWhiteWins, 11, ""},
+		{"two rooks mirrored", "8/8/8/3k4/8/8/8/KR5R w - - 0 1", ResultWhiteWins, 11, ""},
+		{"two black rooks", "kr5r/8/8/8/3K4/8/8/8 b - - 0 1", ResultBlackWins, 11, ""},
+		{"two rooks mate in one", "7k/8/6K1/8/8/8/8/RR6 w - - 0 1", ResultWhiteWins, 1, "Ra8#"},
+		{"two queens", "8/8/4k3/8/8/8/8/Q5QK w - - 0 1", ResultWhiteWins, 5, ""},
+		{"bishops of one colour", "8/8/4k3/8/8/8/8/B5BK w - - 0 1", ResultDraw, 0, ""},
+		{"bishop pair", "8/8/4k3/8/8/8/8/B4B1K w - - 0 1", ResultWhiteWins, 31, ""},
+		{"bishop pair mate in one", "7k/8/5K2/8/8/8/8/BB6 w - - 0 1", ResultWhiteWins, 1, "Kf7#"},
+		{"two knights", "8/8/4k3/8/8/8/8/N5NK w - - 0 1", ResultDraw, 0, ""},
+		{"two knights checkmate", "k7/2NN4/1K6/8/8/8/8/8 b - - 0 1", ResultWhiteWins, 0, ""},
+		{"two knights mate in one", "k7/3N4/1K6/3N4/8/8/8/8 w - - 0 1", ResultWhiteWins, 1, "Nc7#"},
+		{"two knights stalemate", "k7/3N4/1K6/3N4/8/8/8/8 b - - 0 1", ResultDraw, 0, ""},
+		{"bishop and knight", "8/8/4k3/8/8/8/8/B5NK w - - 0 1", ResultWhiteWins, 59, ""},
+		{"bishop and knight in the corner", "7k/8/8/8/8/8/8/KBN5 w - - 0 1", ResultWhiteWins, 57, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res := mustProbe(t, tb, tc.fen)
+			if res.Result != tc.result {
+				t.Fatalf("expected %s, got %s", tc.result, res.Result)
+			}
+
+			if res.DTM != tc.dtm {
+				t.Fatalf("expected mate in %d plies, got %d", tc.dtm, res.DTM)
+			}
+
+			if tc.move != "" && res.Move != tc.move {
+				t.Fatalf("expected %s, got %s", tc.move, res.Move)
+			}
+		})
+	}
+}
+
+func TestTablebasePerfectPlay(t *testing.T) {
+	tb := CreateTablebase()
+	client := mustFEN(t, "8/8/8/4k3/8/8/8/R3K3 w - - 0 1")
+
+	res, err := tb.Probe(client)
+	if err != nil {
+		t.Fatalf("failed to probe: %v", err)
+	}
+
+	// both sides play the tablebase's moves: the fastest mate and the
+	// longest resistance, so each move brings mate a ply closer
+	for dtm := res.DTM; dtm > 0; dtm-- {
+		mustMove(t, client, res.Move)
+
+		if res, err = tb.Probe(client); err != nil {
+			t.Fatalf("failed to probe: %v", err)
+		}
+
+		if res.DTM != dtm-1 || res.Result != ResultWhiteWins {
+			t.Fatalf("expected mate in %d plies after %s, got %d (%s)", dtm-1, client.PGN(), res.DTM, res.Result)
+		}
+	}
+
+	status, err := client.Status()
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+
+	if !status.IsCheckmate {
+		t.Fatalf("expected checkmate after %s", client.PGN())
+	}
+}
+
+func TestTablebaseNotInTablebase(t *testing.T) {
+	tb := CreateTablebase()
+
+	for _, fen := range []string{
+		"4k3/8/8/8/8/8/PP6/4K2Q w - - 0 1",
+		"4k3/8/8/8/8/8/8/R3K3 w Q - 0 1",
+		"4k3/4p3/8/8/8/8/4P3/4K3 w - - 0 1",
+		"8/8/8/4k3/8/8/8/Q6K w - - 0 1", // the side not to move is in check
+	} {
+		if _, err := tb.Probe(mustFEN(t, fen)); !errors.Is(err, ErrNotInTablebase) {
+			t.Fatalf("expected ErrNotInTablebase for %s, got %v", fen, err)
+		}
+	}
+
+	if err := tb.Solve("KPKP"); !errors.Is(err, ErrNotInTablebase) {
+		t.Fatalf("expected ErrNotInTablebase, got %v", err)
+	}
+
+	for _, material := range []string{"", "QK", "KXK", "KQ"} {
+		if err := tb.Solve(material); err == nil {
+			t.Fatalf("expected an error for material %q", material)
+		}
+	}
+}
+
+func TestParseMaterial(t *testing.T) {
+	tests := map[string]string{
+		"KRKP": "KRKP",
+		"KPKR": "KRKP",
+		"knbk": "KBNK",
+		"KKQ":  "KQK",
+		"KPK":  "KPK",
+	}
+
+	for material, want := range tests {
+		sig, err := parseMaterial(material)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", material, err)
+		}
+
+		if sig != want {
+			t.Fatalf("expected %q to be %s, got %s", material, want, sig)
+		}
+	}
+}
+
+func TestTablebaseDir(t *testing.T) {
+	dir := t.TempDir()
+
+	// a table that does not match is solved again
+	if err := os.WriteFile(filepath.Join(dir, "KQK.tbl"), []byte("not a table"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if err := CreateTablebase(TablebaseOptions{Dir: dir}).Solve("KQK"); err != nil {
+		t.Fatalf("failed to solve: %v", err)
+	}
+
+	fn := filepath.Join(dir, "KQK.tbl")
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatalf("expected the table to be saved: %v", err)
+	}
+
+	if res := mustProbe(t, CreateTablebase(TablebaseOptions{Dir: dir}), "7k/8/6K1/8/8/8/8/1Q6 w - - 0 1"); res.DTM != 1 {
+		t.Fatalf("expected mate in 1 ply, got %d", res.DTM)
+	}
+
+	// a table of the right size is loaded rather than solved, which an
+	// emptied table shows by drawing every position
+	if err := os.WriteFile(fn, make([]byte, len(b)), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if res := mustProbe(t, CreateTablebase(TablebaseOptions{Dir: dir}), "7k/8/6K1/8/8/8/8/1Q6 w - - 0 1"); res.Result != ResultDraw {
+		t.Fatalf("expected the table to be loaded, got %s", res.Result)
+	}
+}
@@ -0,0 +1,132 @@
+package chess
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// uciFromKey converts a NotatedMoves lookup key into UCI long algebraic
+// notation (e.g. "e2e4" or "e7e8q"). It returns an empty string when the key
+// is not a valid move in the current position.
+func (c *AlgebraicGameClient) uciFromKey(key string) string {
+	mv, ok := c.notatedMoves[key]
+	if !ok {
+		return ""
+	}
+
+	return mv.Src.name() + mv.Dest.name() + strings.ToLower(promotionOf(key))
+}
+
+// lookupUCI resolves coordinate notation, optionally prefixed with the moving
+// piece (e.g. "Nb1c3"), into the NotatedMoves lookup key for that move.
+func (c *AlgebraicGameClient) lookupUCI(ntn string) (string, bool) {
+	pc := ""
+	if len(ntn) > 0 && strings.ContainsRune("NBRQK", rune(ntn[0])) {
+		pc = ntn[:1]
+		ntn = ntn[1:]
+	}
+
+	if len(ntn) != 4 && len(ntn) != 5 {
+		return "", false
+	}
+
+	ntn = strings.ToLower(ntn)
+	key, ok := c.uciMoves[ntn]
+
+	// accept a king taking its own rook as castling in classical games
+	// (e.g. "e1h1" for "e1g1")
+	if !ok && len(ntn) == 4 {
+		src := c.game.Board.getSquareByName(ntn[:2])
+		dst := c.game.Board.getSquareByName(ntn[2:])
+		if src != nil && dst != nil && dst.Piece != nil {
+			if wing, isCastle := castleWing(src, dst); isCastle {
+				kf, _ := castleDestinations(wing)
+				key, ok = c.uciMoves[ntn[:2]+string(kf)+ntn[3:]]
+			}
+		}
+	}
+
+	if !ok {
+		return "", false
+	}
+
+	// the piece prefix, when provided, must match the piece being moved
+	if pc != "" && c.notatedMoves[key].Src.Piece.Notation != pc {
+		return "", false
+	}
+
+	return key, true
+}
+
+// MoveUCI attempts to make a move using UCI long algebraic notation, where the
+// source and destination squares are followed by an optional promotion piece
+// (e.g. "e2e4", "e1g1" or "e7e8q").
+func (c *AlgebraicGameClient) MoveUCI(uci string) (*moveResult, error) {
+	key, ok := c.lookupUCI(strings.TrimSpace(uci))
+	if !ok {
+		return nil, fmt.Errorf("uci move is invalid (%s)", uci)
+	}
+
+	return c.Move(key)
+}
+
+// UCIMoves returns every legal move in the current position in UCI long
+// algebraic notation, sorted alphabetically.
+func (c *AlgebraicGameClient) UCIMoves() []string {
+	mvs := make([]string, 0, len(c.uciMoves))
+	for uci := range c.uciMoves {
+		mvs = append(mvs, uci)
+	}
+	slices.Sort(mvs)
+
+	return mvs
+}
+
+// UCIToSAN converts a legal move in UCI long algebraic notation into Standard
+// Algebraic Notation for the current position.
+func (c *AlgebraicGameClient) UCIToSAN(uci string) (string, error) {
+	key, ok := c.lookupUCI(strings.TrimSpace(uci))
+	if !ok {
+		return "", fmt.Errorf("uci move is invalid (%s)", uci)
+	}
+
+	return toSAN(key), nil
+}
+
+// SANToUCI converts a legal move in algebraic notation into UCI long algebraic
+// notation for the current position.
+func (c *AlgebraicGameClient) SANToUCI(san string) (string, error) {
+	key, ok := c.keys[sanitizeNotation(san, c.options.PGN)]
+	if !ok {
+		return "", fmt.Errorf("notation is invalid (%s)", san)
+	}
+
+	return c.uciFromKey(key), nil
+}
+
+// uciPosition returns the UCI "position" command for the current position:
+// the starting position of the game followed by the moves played since, so
+// that an engine can detect repetitions. Chess960 castling moves are written
+// as the king taking its own rook.
+func (c *AlgebraicGameClient) uciPosition() string {
+	cmd := "position startpos"
+	if c.fen != "" {
+		cmd = "position fen " + c.fen
+	}
+
+	if len(c.game.MoveHistory) == 0 {
+		return cmd
+	}
+
+	mvs := make([]string, 0, len(c.game.MoveHistory))
+	for _, mv := range c.game.MoveHistory {
+		dest := mv.PostSquare
+		if mv.Castle && c.game.c960 {
+			dest = mv.RookSource
+		}
+		mvs = append(mvs, mv.PrevSquare.name()+dest.name()+strings.ToLower(promotionOf(mv.Algebraic)))
+	}
+
+	return cmd + " moves " + strings.Join(mvs, " ")
+}
@@ -0,0 +1,563 @@
+package chess
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// uciQuitTimeout is how long an engine process is given to exit after "quit".
+const uciQuitTimeout = 2 * time.Second
+
+// ErrUCIEngineExited is returned once the output of an engine has ended.
+var ErrUCIEngineExited = errors.New("uci: engine exited")
+
+// UCIClientOptions configures the connection to an external UCI engine.
+type UCIClientOptions struct {
+	Args    []string          // Args are the command line arguments of the engine process.
+	Options map[string]string // Options are set on the engine once it has started (e.g. "Threads": "4").
+}
+
+// UCIOption is an option advertised by an engine.
+type UCIOption struct {
+	Default string   // Default is the default value of the option.
+	Max     string   // Max is the maximum value of a spin option.
+	Min     string   // Min is the minimum value of a spin option.
+	Name    string   // Name is the name of the option (e.g. "Hash").
+	Type    string   // Type is one of check, spin, combo, button or string.
+	Vars    []string // Vars are the values of a combo option.
+}
+
+// UCIInfo is information reported by an engine while searching. Fields the
+// engine did not report are left at zero.
+type UCIInfo struct {
+	Depth      int           // Depth is the depth of the search in plies.
+	LowerBound bool          // LowerBound is true when the score is only a lower bound.
+	Mate       int           // Mate is the number of moves to a forced mate, negative when the side to move is mated.
+	MultiPV    int           // MultiPV is the rank of the line when several lines are reported.
+	Nodes      uint64        // Nodes is the number of positions searched.
+	NPS        uint64        // NPS is the number of positions searched per second.
+	PV         []string      // PV is the principal variation in UCI notation.
+	PVSAN      []string      // PVSAN is the principal variation in Standard Algebraic Notation.
+	Score      int           // Score is the evaluation in centipawns from the point of view of the side to move.
+	SelDepth   int           // SelDepth is the selective depth of the search in plies.
+	String     string        // String is the text of an "info string" line.
+	Time       time.Duration // Time is the duration of the search.
+	UpperBound bool          // UpperBound is true when the score is only an upper bound.
+}
+
+// UCIBestMove is the move chosen by an engine once its search has ended.
+type UCIBestMove struct {
+	Move   string // Move is the best move in Standard Algebraic Notation.
+	Ponder string // Ponder is the reply the engine expects in UCI notation (empty when none).
+	UCI    string // UCI is the best move in UCI notation.
+}
+
+// uciClient drives an external engine over the Universal Chess Interface
+// (UCI) protocol. A client is not safe for concurrent use and runs a single
+// search at a time.
+type uciClient struct {
+	author  string
+	cmd     *exec.Cmd   // cmd is the engine process, when the client started one.
+	lines   chan string // lines are read from the engine, and closed once its output ends.
+	name    string
+	options map[string]UCIOption // options are keyed by their lower case name.
+	w       io.WriteCloser
+}
+
+// CreateUCIClient starts the engine at path and performs the UCI handshake,
+// setting any options provided. The context bounds the start of the engine
+// only; Close ends the engine process.
+func CreateUCIClient(ctx context.Context, path string, opts ...UCIClientOptions) (*uciClient, error) {
+	var o UCIClientOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	cmd := exec.Command(path, o.Args...)
+
+	w, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	uc, err := connectUCIClient(ctx, r, w, o)
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, err
+	}
+	uc.cmd = cmd
+
+	return uc, nil
+}
+
+// connectUCIClient performs the UCI handshake with an engine reading
+// commands from w and writing responses to r.
+func connectUCIClient(ctx context.Context, r io.Reader, w io.WriteCloser, o UCIClientOptions) (*uciClient, error) {
+	uc := &uciClient{
+		lines:   make(chan string, 64),
+		options: map[string]UCIOption{},
+		w:       w,
+	}
+
+	go func() {
+		defer close(uc.lines)
+
+		scn := bufio.NewScanner(r)
+		for scn.Scan() {
+			uc.lines <- scn.Text()
+		}
+	}()
+
+	if err := uc.send("uci"); err != nil {
+		return nil, err
+	}
+
+	for {
+		ln, err := uc.readLine(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if ln == "uciok" {
+			break
+		}
+
+		switch {
+		case strings.HasPrefix(ln, "id name "):
+			uc.name = strings.TrimPrefix(ln, "id name ")
+		case strings.HasPrefix(ln, "id author "):
+			uc.author = strings.TrimPrefix(ln, "id author ")
+		case strings.HasPrefix(ln, "option "):
+			opt := parseUCIOption(strings.Fields(ln)[1:])
+			uc.options[strings.ToLower(opt.Name)] = opt
+		}
+	}
+
+	names := make([]string, 0, len(o.Options))
+	for name := range o.Options {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		if err := uc.SetOption(ctx, name, o.Options[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	return uc, uc.sync(ctx)
+}
+
+// parseUCIOption parses the fields of an "option" line following "option".
+func parseUCIOption(flds []string) UCIOption {
+	var opt UCIOption
+
+	// values run until the next keyword, as names may contain spaces
+	keywords := []string{"name", "type", "default", "min", "max", "var"}
+	for i := 0; i < len(flds); {
+		kw := flds[i]
+		j := i + 1
+		for j < len(flds) && !slices.Contains(keywords, flds[j]) {
+			j++
+		}
+		val := strings.Join(flds[i+1:j], " ")
+
+		switch kw {
+		case "name":
+			opt.Name = val
+		case "type":
+			opt.Type = val
+		case "default":
+			opt.Default = val
+		case "min":
+			opt.Min = val
+		case "max":
+			opt.Max = val
+		case "var":
+			opt.Vars = append(opt.Vars, val)
+		}
+		i = j
+	}
+
+	return opt
+}
+
+// Author returns the author reported by the engine.
+func (uc *uciClient) Author() string {
+	return uc.author
+}
+
+// Name returns the name reported by the engine.
+func (uc *uciClient) Name() string {
+	return uc.name
+}
+
+// Options returns the options advertised by the engine, sorted by name.
+func (uc *uciClient) Options() []UCIOption {
+	opts := make([]UCIOption, 0, len(uc.options))
+	for _, opt := range uc.options {
+		opts = append(opts, opt)
+	}
+	slices.SortFunc(opts, func(a, b UCIOption) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return opts
+}
+
+// SetOption sets an option advertised by the engine, waiting for the engine
+// to be ready. The value of a button option is ignored.
+func (uc *uciClient) SetOption(ctx context.Context, name, value string) error {
+	opt, ok := uc.options[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("uci: engine has no option %s", name)
+	}
+
+	cmd := "setoption name " + opt.Name
+	if opt.Type != "button" {
+		cmd += " value " + value
+	}
+
+	if err := uc.send(cmd); err != nil {
+		return err
+	}
+
+	return uc.sync(ctx)
+}
+
+// NewGame tells the engine that the next search is of a different game.
+func (uc *uciClient) NewGame(ctx context.Context) error {
+	if err := uc.send("ucinewgame"); err != nil {
+		return err
+	}
+
+	return uc.sync(ctx)
+}
+
+// Close asks the engine to quit, ending the engine process when the client
+// started one.
+func (uc *uciClient) Close() error {
+	_ = uc.send("quit")
+	err := uc.w.Close()
+
+	if uc.cmd == nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- uc.cmd.Wait()
+	}()
+
+	select {
+	case err = <-done:
+	case <-time.After(uciQuitTimeout):
+		_ = uc.cmd.Process.Kill()
+		err = <-done
+	}
+
+	return err
+}
+
+// send writes a command to the engine.
+func (uc *uciClient) send(cmd string) error {
+	_, err := io.WriteString(uc.w, cmd+"\n")
+	return err
+}
+
+// readLine returns the next line written by the engine.
+func (uc *uciClient) readLine(ctx context.Context) (string, error) {
+	select {
+	case ln, ok := <-uc.lines:
+		if !ok {
+			return "", ErrUCIEngineExited
+		}
+
+		return ln, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// sync waits for the engine to process the commands sent so far.
+func (uc *uciClient) sync(ctx context.Context) error {
+	if err := uc.send("isready"); err != nil {
+		return err
+	}
+
+	for {
+		ln, err := uc.readLine(ctx)
+		if err != nil {
+			return err
+		}
+
+		if ln == "readyok" {
+			return nil
+		}
+	}
+}
+
+// uciSearch is a search in progress on an external engine.
+type uciSearch struct {
+	best *UCIBestMove
+	done chan struct{} // done is closed once the search has ended.
+	err  error
+	info chan UCIInfo
+}
+
+// Search sends the current position of the client, along with the moves of
+// the game leading to it, and starts a search within the given limits. The
+// engine searches until a limit is reached or, when no limits are given,
+// until ctx is cancelled; cancelling ctx stops the search and the engine
+// still reports its best move. Moves reported by the engine are converted
+// into Standard Algebraic Notation against the client's legal moves. Chess960
+// games can only be searched by engines that support UCI_Chess960.
+func (uc *uciClient) Search(ctx context.Context, c *AlgebraicGameClient, limits SearchLimits) (*uciSearch, error) {
+	if c.game.c960 {
+		if _, ok := uc.options["uci_chess960"]; !ok {
+			return nil, fmt.Errorf("uci: %s does not support Chess960", uc.name)
+		}
+
+		if err := uc.SetOption(ctx, "UCI_Chess960", "true"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uc.send(c.uciPosition()); err != nil {
+		return nil, err
+	}
+
+	if err := uc.send(uciGo(limits)); err != nil {
+		return nil, err
+	}
+
+	s := &uciSearch{done: make(chan struct{}), info: make(chan UCIInfo, 16)}
+	go s.read(ctx, uc, c.Position())
+
+	return s, nil
+}
+
+// Info returns the information reported by the engine as it searches. The
+// channel is closed once the search has ended, and must be drained (or Wait
+// called) for the search to proceed.
+func (s *uciSearch) Info() <-chan UCIInfo {
+	return s.info
+}
+
+// Wait waits for the search to end, discarding any information not yet
+// received from Info, and returns the best move of the engine.
+func (s *uciSearch) Wait() (*UCIBestMove, error) {
+	for range s.info {
+	}
+	<-s.done
+
+	return s.best, s.err
+}
+
+// read parses the output of the engine until it reports its best move.
+func (s *uciSearch) read(ctx context.Context, uc *uciClient, pos *Position) {
+	defer close(s.done)
+	defer close(s.info)
+
+	// the engine reports its best move once stopped
+	stop := ctx.Done()
+	halt := func() bool {
+		stop = nil
+		s.err = uc.send("stop")
+		return s.err == nil
+	}
+
+	for {
+		var ln string
+		select {
+		case <-stop:
+			if !halt() {
+				return
+			}
+			continue
+		case l, ok := <-uc.lines:
+			if !ok {
+				s.err = ErrUCIEngineExited
+				return
+			}
+			ln = l
+		}
+
+		flds := strings.Fields(ln)
+		if len(flds) == 0 {
+			continue
+		}
+
+		switch flds[0] {
+		case "info":
+			info := parseUCIInfo(flds[1:])
+			info.PVSAN = uciToSANLine(pos, info.PV)
+			if stop == nil {
+				// once stopped, information that is not being read is dropped
+				select {
+				case s.info <- info:
+				default:
+				}
+				continue
+			}
+
+			select {
+			case s.info <- info:
+			case <-stop:
+				if !halt() {
+					return
+				}
+			}
+		case "bestmove":
+			s.best, s.err = parseUCIBestMove(flds[1:], pos)
+			return
+		}
+	}
+}
+
+// parseUCIInfo parses the fields of an "info" line following "info".
+func parseUCIInfo(flds []string) UCIInfo {
+	var info UCIInfo
+
+	for i := 0; i < len(flds); i++ {
+		switch flds[i] {
+		case "string":
+			info.String = strings.Join(flds[i+1:], " ")
+			return info
+		case "pv":
+			info.PV = slices.Clone(flds[i+1:])
+			return info
+		case "lowerbound":
+			info.LowerBound = true
+			continue
+		case "upperbound":
+			info.UpperBound = true
+			continue
+		case "score":
+			// the score is followed by its unit (e.g. "score cp 31" or "score mate -2")
+			if i+2 < len(flds) {
+				n, _ := strconv.Atoi(flds[i+2])
+				if flds[i+1] == "mate" {
+					info.Mate = n
+				} else {
+					info.Score = n
+				}
+				i += 2
+			}
+			continue
+		}
+
+		if i+1 >= len(flds) {
+			break
+		}
+
+		n, err := strconv.ParseUint(flds[i+1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		switch flds[i] {
+		case "depth":
+			info.Depth = int(n)
+		case "seldepth":
+			info.SelDepth = int(n)
+		case "multipv":
+			info.MultiPV = int(n)
+		case "nodes":
+			info.Nodes = n
+		case "nps":
+			info.NPS = n
+		case "time":
+			info.Time = time.Duration(n) * time.Millisecond
+		}
+		i++
+	}
+
+	return info
+}
+
+// parseUCIBestMove parses the fields of a "bestmove" line following
+// "bestmove", notating the move for the position searched.
+func parseUCIBestMove(flds []string, pos *Position) (*UCIBestMove, error) {
+	if len(flds) == 0 || flds[0] == "(none)" || flds[0] == "0000" {
+		return nil, errors.New("uci: engine reported no best move")
+	}
+
+	san := uciToSANLine(pos, flds[:1])
+	if len(san) == 0 {
+		return nil, fmt.Errorf("uci: engine reported an illegal move (%s)", flds[0])
+	}
+
+	best := &UCIBestMove{Move: san[0], UCI: flds[0]}
+	if len(flds) > 2 && flds[1] == "ponder" {
+		best.Ponder = flds[2]
+	}
+
+	return best, nil
+}
+
+// uciToSANLine notates a line of moves in UCI notation played from the
+// position, stopping at the first illegal move. The moves are made and
+// unmade on the position, which is returned as it was.
+func uciToSANLine(p *Position, ucis []string) []string {
+	san := make([]string, 0, len(ucis))
+	sts := make([]MoveState, 0, len(ucis))
+
+	var buf [256]Move
+	for _, uci := range ucis {
+		mvs := p.LegalMoves(buf[:0])
+		i := slices.IndexFunc(mvs, func(m Move) bool { return p.UCI(m) == uci })
+		if i < 0 {
+			break
+		}
+
+		san = append(san, p.san(mvs[i]))
+		sts = append(sts, p.MakeMove(mvs[i]))
+	}
+
+	for i := len(sts) - 1; i >= 0; i-- {
+		p.UnmakeMove(sts[i])
+	}
+
+	return san
+}
+
+// uciGo returns the "go" command searching within the limits.
+func uciGo(limits SearchLimits) string {
+	cmd := "go"
+	if limits.Depth > 0 {
+		cmd += " depth " + strconv.Itoa(limits.Depth)
+	}
+
+	if limits.Nodes > 0 {
+		cmd += " nodes " + strconv.FormatUint(limits.Nodes, 10)
+	}
+
+	if limits.Time > 0 {
+		cmd += " movetime " + strconv.FormatInt(max(limits.Time.Milliseconds(), 1), 10)
+	}
+
+	if cmd == "go" {
+		cmd += " infinite"
+	}
+
+	return cmd
+}
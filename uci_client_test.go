@@ -0,0 +1,443 @@
+package chess
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeUCIEngine is a scripted engine, answering the handshake and replying
+// to every "go" with the lines of its script. An infinite search waits for
+// "stop" before its final (bestmove) line.
+type fakeUCIEngine struct {
+	cmds   []string
+	mu     sync.Mutex
+	script []string
+}
+
+// commands returns the commands received by the engine.
+func (fe *fakeUCIEngine) commands() []string {
+	fe.mu.Lock()
+	defer fe.mu.Unlock()
+
+	return slices.Clone(fe.cmds)
+}
+
+// run plays the engine until "quit" is received or its input ends, then
+// closes both pipes as an exiting process would.
+func (fe *fakeUCIEngine) run(r *io.PipeReader, w io.WriteCloser) {
+	defer r.Close()
+	defer w.Close()
+
+	write := func(lns ...string) {
+		for _, ln := range lns {
+			io.WriteString(w, ln+"\n")
+		}
+	}
+
+	scn := bufio.NewScanner(r)
+	read := func() (string, bool) {
+		if !scn.Scan() {
+			return "", false
+		}
+
+		fe.mu.Lock()
+		defer fe.mu.Unlock()
+		fe.cmds = append(fe.cmds, scn.Text())
+
+		return scn.Text(), true
+	}
+
+	for cmd, ok := read(); ok; cmd, ok = read() {
+
+		switch {
+		case cmd == "uci":
+			write(
+				"id name Fake Engine 1.0",
+				"id author Test Author",
+				"option name Hash type spin default 16 min 1 max 1024",
+				"option name Skill Level type combo default Normal var Easy var Normal var Hard",
+				"option name UCI_Chess960 type check default false",
+				"option name Clear Hash type button",
+				"uciok")
+		case cmd == "isready":
+			write("readyok")
+		case cmd == "go infinite":
+			write(fe.script[:len(fe.script)-1]...)
+			for cmd, ok := read(); ok && cmd != "stop"; cmd, ok = read() {
+			}
+			write(fe.script[len(fe.script)-1])
+		case strings.HasPrefix(cmd, "go"):
+			write(fe.script...)
+		case cmd == "quit":
+			return
+		}
+	}
+}
+
+// connectFakeUCIEngine connects a client to a scripted engine.
+func connectFakeUCIEngine(t *testing.T, script []string, opts ...UCIClientOptions) (*uciClient, *fakeUCIEngine) {
+	t.Helper()
+
+	var o UCIClientOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+
+	fe := &fakeUCIEngine{script: script}
+	go fe.run(inR, outW)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	uc, err := connectUCIClient(ctx, outR, inW, o)
+	if err != nil {
+		t.Fatalf("handshake failed: %v", err)
+	}
+	t.Cleanup(func() { uc.Close() })
+
+	return uc, fe
+}
+
+func TestUCIClientHandshake(t *testing.T) {
+	uc, fe := connectFakeUCIEngine(t, nil, UCIClientOptions{Options: map[string]string{"hash": "64"}})
+
+	if uc.Name() != "Fake Engine 1.0" || uc.Author() != "Test Author" {
+		t.Fatalf("expected the engine's identity, got %q by %q", uc.Name(), uc.Author())
+	}
+
+	opts := uc.Options()
+	names := []string{}
+	for _, opt := range opts {
+		names = append(names, opt.Name)
+	}
+	if !slices.Equal(names, []string{"Clear Hash", "Hash", "Skill Level", "UCI_Chess960"}) {
+		t.Fatalf("expected the advertised options, got %v", names)
+	}
+
+	if skl := opts[2]; skl.Type != "combo" || skl.Default != "Normal" || !slices.Equal(skl.Vars, []string{"Easy", "Normal", "Hard"}) {
+		t.Fatalf("expected the combo option to be parsed, got %+v", skl)
+	}
+
+	if hash := opts[1]; hash.Type != "spin" || hash.Min != "1" || hash.Max != "1024" || hash.Default != "16" {
+		t.Fatalf("expected the spin option to be parsed, got %+v", hash)
+	}
+
+	if !slices.Contains(fe.commands(), "setoption name Hash value 64") {
+		t.Fatalf("expected the option to be set, got %v", fe.commands())
+	}
+}
+
+func TestUCIClientSetOption(t *testing.T) {
+	uc, fe := connectFakeUCIEngine(t, nil)
+	ctx := context.Background()
+
+	if err := uc.SetOption(ctx, "Skill Level", "Hard"); err != nil {
+		t.Fatalf("failed to set option: %v", err)
+	}
+
+	if err := uc.SetOption(ctx, "Clear Hash", ""); err != nil {
+		t.Fatalf("failed to press button: %v", err)
+	}
+
+	if err := uc.SetOption(ctx, "Contempt", "10"); err == nil {
+		t.Fatalf("expected an error for an option the engine does not have")
+	}
+
+	if err := uc.NewGame(ctx); err != nil {
+		t.Fatalf("failed to start a new game: %v", err)
+	}
+
+	cmds := fe.commands()
+	for _, want := range []string{"setoption name Skill Level value Hard", "setoption name Clear Hash", "ucinewgame"} {
+		if !slices.Contains(cmds, want) {
+			t.Fatalf("expected %q to be sent, got %v", want, cmds)
+		}
+	}
+}
+
+func TestUCIClientSearch(t *testing.T) {
+	uc, fe := connectFakeUCIEngine(t, []string{
+		"info string searching",
+		"info depth 1 seldepth 2 multipv 1 score cp 31 nodes 20 nps 20000 time 1 pv g1f3",
+		"info depth 2 seldepth 4 multipv 1 score cp 25 lowerbound nodes 120 nps 60000 time 2 pv g1f3 b8c6",
+		"bestmove g1f3 ponder b8c6",
+	})
+
+	client := CreateAlgebraicGameClient()
+	mustMove(t, client, "e4")
+	mustMove(t, client, "e5")
+
+	s, err := uc.Search(context.Background(), client, SearchLimits{Depth: 2})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+
+	var infos []UCIInfo
+	for info := range s.Info() {
+		infos = append(infos, info)
+	}
+
+	best, err := s.Wait()
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+
+	if *best != (UCIBestMove{Move: "Nf3", Ponder: "b8c6", UCI: "g1f3"}) {
+		t.Fatalf("expected Nf3, got %+v", best)
+	}
+
+	if len(infos) != 3 || infos[0].String != "searching" {
+		t.Fatalf("expected three info lines, got %+v", infos)
+	}
+
+	info := infos[2]
+	if info.Depth != 2 || info.SelDepth != 4 || info.MultiPV != 1 || info.Score != 25 || !info.LowerBound ||
+		info.Nodes != 120 || info.NPS != 60000 || info.Time != 2*time.Millisecond {
+		t.Fatalf("expected the info line to be parsed, got %+v", info)
+	}
+
+	if !slices.Equal(info.PV, []string{"g1f3", "b8c6"}) || !slices.Equal(info.PVSAN, []string{"Nf3", "Nc6"}) {
+		t.Fatalf("expected the principal variation in both notations, got %v and %v", info.PV, info.PVSAN)
+	}
+
+	// the game is sent from its start so that repetitions are known
+	cmds := fe.commands()
+	if !slices.Contains(cmds, "position startpos moves e2e4 e7e5") || !slices.Contains(cmds, "go depth 2") {
+		t.Fatalf("expected the position and limits to be sent, got %v", cmds)
+	}
+
+	if client.Ply() != 2 {
+		t.Fatalf("expected the client to be left untouched, got ply %d", client.Ply())
+	}
+}
+
+func TestUCIClientSearchChess960(t *testing.T) {
+	uc, fe := connectFakeUCIEngine(t, []string{"bestmove g1f3"})
+
+	client, err := CreateChess960GameClient(518)
+	if err != nil {
+		t.Fatalf("failed to create game: %v", err)
+	}
+
+	s, err := uc.Search(context.Background(), client, SearchLimits{Depth: 1})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+
+	if _, err := s.Wait(); err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+
+	if cmds := fe.commands(); !slices.Contains(cmds, "setoption name UCI_Chess960 value true") {
+		t.Fatalf("expected Chess960 to be enabled, got %v", cmds)
+	}
+
+	// an engine that does not support Chess960 is not sent the game
+	delete(uc.options, "uci_chess960")
+	if _, err := uc.Search(context.Background(), client, SearchLimits{Depth: 1}); err == nil {
+		t.Fatalf("expected an error for an engine without Chess960 support")
+	}
+}
+
+func TestUCIClientCancel(t *testing.T) {
+	uc, fe := connectFakeUCIEngine(t, []string{
+		"info depth 1 score mate 2 pv d8h4",
+		"bestmove d8h4",
+	})
+
+	client := CreateAlgebraicGameClient()
+	for _, ntn := range []string{"f3", "e5", "g4"} {
+		mustMove(t, client, ntn)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s, err := uc.Search(ctx, client, SearchLimits{})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+
+	if info := <-s.Info(); info.Mate != 2 || !slices.Equal(info.PVSAN, []string{"Qh4#"}) {
+		t.Fatalf("expected a mate score, got %+v", info)
+	}
+
+	// the engine searches until stopped
+	cancel()
+
+	best, err := s.Wait()
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+
+	if best.Move != "Qh4#" {
+		t.Fatalf("expected Qh4#, got %s", best.Move)
+	}
+
+	if cmds := fe.commands(); !slices.Contains(cmds, "go infinite") || !slices.Contains(cmds, "stop") {
+		t.Fatalf("expected an infinite search to be stopped, got %v", cmds)
+	}
+}
+
+func TestUCIClientCancelUndrained(t *testing.T) {
+	// more information than is buffered, none of which is read
+	script := slices.Repeat([]string{"info depth 1 score cp 20 pv e2e4"}, 64)
+	uc, fe := connectFakeUCIEngine(t, append(script, "bestmove e2e4"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s, err := uc.Search(ctx, CreateAlgebraicGameClient(), SearchLimits{})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+
+	// the search is cancelled once the information fills its buffer
+	for len(s.Info()) < cap(s.Info()) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	// the engine is stopped without Info being drained or Wait called
+	for deadline := time.Now().Add(5 * time.Second); !slices.Contains(fe.commands(), "stop"); {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the engine to be stopped, got %v", fe.commands())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	best, err := s.Wait()
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+
+	if best.Move != "e4" {
+		t.Fatalf("expected e4, got %s", best.Move)
+	}
+}
+
+func TestUCIClientIllegalBestMove(t *testing.T) {
+	uc, _ := connectFakeUCIEngine(t, []string{"bestmove e2e5"})
+
+	s, err := uc.Search(context.Background(), CreateAlgebraicGameClient(), SearchLimits{Nodes: 100})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+
+	if _, err := s.Wait(); err == nil {
+		t.Fatalf("expected an error for an illegal move")
+	}
+}
+
+func TestUCIClientEngineExited(t *testing.T) {
+	uc, _ := connectFakeUCIEngine(t, []string{"bestmove e2e4"})
+
+	uc.send("quit")
+
+	s, err := uc.Search(context.Background(), CreateAlgebraicGameClient(), SearchLimits{Depth: 1})
+	if err == nil {
+		_, err = s.Wait()
+	}
+
+	if err == nil {
+		t.Fatalf("expected an error once the engine has exited")
+	}
+}
+
+func TestUCIPosition(t *testing.T) {
+	t.Run("promotion", func(t *testing.T) {
+		client := mustFEN(t, "r3k3/1P6/8/8/8/8/8/R3K2R w KQq - 0 1")
+		for _, ntn := range []string{"b8=N", "Kf7", "O-O"} {
+			mustMove(t, client, ntn)
+		}
+
+		if want := "position fen r3k3/1P6/8/8/8/8/8/R3K2R w KQq - 0 1 moves b7b8n e8f7 e1g1"; client.uciPosition() != want {
+			t.Fatalf("expected %q, got %q", want, client.uciPosition())
+		}
+	})
+
+	t.Run("chess960", func(t *testing.T) {
+		client := mustFEN(t, "rk2r3/pppppppp/8/8/8/8/PPPPPPPP/RK2R3 w KQkq - 0 1")
+		mustMove(t, client, "O-O-O")
+
+		if want := "position fen rk2r3/pppppppp/8/8/8/8/PPPPPPPP/RK2R3 w KQkq - 0 1 moves b1a1"; client.uciPosition() != want {
+			t.Fatalf("expected %q, got %q", want, client.uciPosition())
+		}
+	})
+}
+
+func TestUCIGo(t *testing.T) {
+	tests := []struct {
+		limits SearchLimits
+		want   string
+	}{
+		{SearchLimits{}, "go infinite"},
+		{SearchLimits{Depth: 12}, "go depth 12"},
+		{SearchLimits{Nodes: 5000, Time: 1500 * time.Millisecond}, "go nodes 5000 movetime 1500"},
+	}
+
+	for _, tc := range tests {
+		if got := uciGo(tc.limits); got != tc.want {
+			t.Fatalf("expected %q, got %q", tc.want, got)
+		}
+	}
+}
+
+// TestUCIEngineProcess runs the built-in engine when the test binary is
+// started as an engine process by TestUCIClientProcess.
+func TestUCIEngineProcess(t *testing.T) {
+	if os.Getenv("CHESS_UCI_ENGINE_PROCESS") != "1" {
+		t.Skip("run as an engine process by TestUCIClientProcess")
+	}
+
+	if err := CreateUCIEngine(os.Stdin, os.Stdout).Run(context.Background()); err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestUCIClientProcess(t *testing.T) {
+	t.Setenv("CHESS_UCI_ENGINE_PROCESS", "1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	uc, err := CreateUCIClient(ctx, os.Args[0], UCIClientOptions{Args: []string{"-test.run=^TestUCIEngineProcess$"}})
+	if err != nil {
+		t.Fatalf("failed to start the engine: %v", err)
+	}
+
+	if uc.Name() != defaultEngineName {
+		t.Fatalf("expected the built-in engine, got %q", uc.Name())
+	}
+
+	client := mustFEN(t, "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1")
+	s, err := uc.Search(ctx, client, SearchLimits{Depth: 3})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+
+	best, err := s.Wait()
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+
+	if best.Move != "Ra8#" {
+		t.Fatalf("expected Ra8#, got %s", best.Move)
+	}
+
+	if err := uc.Close(); err != nil {
+		t.Fatalf("failed to close the engine: %v", err)
+	}
+
+	if _, err := CreateUCIClient(ctx, "/nonexistent/engine"); err == nil || errors.Is(err, ErrUCIEngineExited) {
+		t.Fatalf("expected an error starting a missing engine, got %v", err)
+	}
+}
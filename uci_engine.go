@@ -0,0 +1,312 @@
+package chess
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultEngineAuthor = "brozeph"       // defaultEngineAuthor is the author reported by an engine.
+	defaultEngineName   = "brozeph/chess" // defaultEngineName is the name reported by an engine.
+	defaultMovesToGo    = 30              // defaultMovesToGo is the number of moves the remaining time is shared between.
+)
+
+// UCIEngineOptions configures an engine speaking the UCI protocol.
+type UCIEngineOptions struct {
+	Author string // Author is the author reported to the GUI ("brozeph" when empty).
+	Name   string // Name is the name reported to the GUI ("brozeph/chess" when empty).
+}
+
+// uciEngine plays as an engine over the Universal Chess Interface (UCI)
+// protocol, searching positions with BestMove.
+type uciEngine struct {
+	c960   bool
+	cancel context.CancelFunc // cancel stops the search in progress.
+	client *AlgebraicGameClient
+	done   chan struct{} // done is closed once the search in progress has reported its best move.
+	mu     sync.Mutex    // mu serialises writes to the GUI.
+	opts   UCIEngineOptions
+	r      io.Reader
+	w      io.Writer
+}
+
+// CreateUCIEngine creates an engine that reads UCI commands from r and writes
+// its responses to w, such as the standard input and output of an engine
+// process loaded by a chess GUI.
+func CreateUCIEngine(r io.Reader, w io.Writer, opts ...UCIEngineOptions) *uciEngine {
+	var o UCIEngineOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	if o.Author == "" {
+		o.Author = defaultEngineAuthor
+	}
+
+	if o.Name == "" {
+		o.Name = defaultEngineName
+	}
+
+	return &uciEngine{
+		client: CreateAlgebraicGameClient(AlgebraicClientOptions{AllowMovesAfterGameOver: true}),
+		opts:   o,
+		r:      r,
+		w:      w,
+	}
+}
+
+// Run handles commands until "quit" is received or the input ends. Searches
+// run in the background, so that "stop" and "isready" are answered while
+// searching, and are derived from ctx. Cancelling ctx stops any search and
+// ends Run once the next command is read.
+func (e *uciEngine) Run(ctx context.Context) error {
+	defer e.stop()
+
+	scn := bufio.NewScanner(e.r)
+	for scn.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if quit := e.handle(ctx, strings.Fields(scn.Text())); quit {
+			return nil
+		}
+	}
+
+	return scn.Err()
+}
+
+// handle responds to a single command, returning true once the engine should quit.
+func (e *uciEngine) handle(ctx context.Context, args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	switch args[0] {
+	case "uci":
+		e.send("id name %s", e.opts.Name)
+		e.send("id author %s", e.opts.Author)
+		e.send("option name UCI_Chess960 type check default false")
+		e.send("uciok")
+	case "isready":
+		e.send("readyok")
+	case "setoption":
+		e.setOption(args[1:])
+	case "ucinewgame":
+		e.stop()
+		e.client = CreateAlgebraicGameClient(e.clientOptions())
+	case "position":
+		e.stop()
+		e.position(args[1:])
+	case "go":
+		e.stop()
+		e.search(ctx, args[1:])
+	case "stop":
+		e.stop()
+	case "quit":
+		return true
+	case "debug", "ponderhit", "register":
+		// not supported, but not errors either
+	default:
+		e.send("info string unknown command %s", args[0])
+	}
+
+	return false
+}
+
+// send writes a line to the GUI.
+func (e *uciEngine) send(format string, a ...any) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	fmt.Fprintf(e.w, format+"\n", a...)
+}
+
+// clientOptions returns the options of the clients holding positions.
+func (e *uciEngine) clientOptions() AlgebraicClientOptions {
+	return AlgebraicClientOptions{AllowMovesAfterGameOver: true, Chess960: e.c960}
+}
+
+// setOption handles "setoption name <id> [value <x>]".
+func (e *uciEngine) setOption(args []string) {
+	vi := slices.Index(args, "value")
+	if len(args) < 2 || args[0] != "name" || vi < 0 {
+		return
+	}
+
+	name, val := strings.Join(args[1:vi], " "), strings.Join(args[vi+1:], " ")
+	if strings.EqualFold(name, "UCI_Chess960") {
+		e.c960 = val == "true"
+	}
+}
+
+// position handles "position [startpos | fen <fen>] [moves <move>...]".
+func (e *uciEngine) position(args []string) {
+	if len(args) == 0 {
+		return
+	}
+
+	mvs := []string{}
+	if mi := slices.Index(args, "moves"); mi >= 0 {
+		args, mvs = args[:mi], args[mi+1:]
+	}
+
+	var client *AlgebraicGameClient
+	switch args[0] {
+	case "startpos":
+		client = CreateAlgebraicGameClient(e.clientOptions())
+	case "fen":
+		var err error
+		client, err = CreateAlgebraicGameClientFromFEN(strings.Join(args[1:], " "), e.clientOptions())
+		if err != nil {
+			e.send("info string invalid position: %v", err)
+			return
+		}
+	default:
+		e.send("info string invalid position: %s", args[0])
+		return
+	}
+
+	for _, mv := range mvs {
+		if _, err := client.MoveUCI(mv); err != nil {
+			e.send("info string invalid move: %v", err)
+			return
+		}
+	}
+
+	e.client = client
+}
+
+// search handles "go", searching in the background until a limit is reached
+// or the search is stopped. The best move of an infinite search is reported
+// once it is stopped.
+func (e *uciEngine) search(ctx context.Context, args []string) {
+	limits, infinite := e.limits(args)
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	e.cancel, e.done = cancel, done
+
+	client := e.client
+	go func() {
+		defer close(done)
+
+		res, err := client.bestMove(ctx, limits, e.info)
+		if err != nil {
+			e.send("info string %v", err)
+			e.send("bestmove 0000")
+			return
+		}
+
+		if infinite {
+			<-ctx.Done()
+		}
+
+		if len(res.PV) > 1 {
+			e.send("bestmove %s ponder %s", res.UCI, res.PV[1])
+			return
+		}
+
+		e.send("bestmove %s", res.UCI)
+	}()
+}
+
+// limits parses the arguments of "go" into limits of the search, reporting
+// whether the search is infinite.
+func (e *uciEngine) limits(args []string) (SearchLimits, bool) {
+	var limits SearchLimits
+	var clk [2]time.Duration
+	var inc [2]time.Duration
+	mtg, infinite := 0, false
+
+	for i := 0; i < len(args); i++ {
+		if args[i] == "infinite" || args[i] == "ponder" {
+			infinite = true
+			continue
+		}
+
+		if i+1 >= len(args) {
+			break
+		}
+
+		n, err := strconv.ParseInt(args[i+1], 10, 64)
+		if err != nil {
+			continue
+		}
+		i++
+
+		switch args[i-1] {
+		case "depth":
+			limits.Depth = int(n)
+		case "nodes":
+			limits.Nodes = uint64(n)
+		case "movetime":
+			limits.Time = time.Duration(n) * time.Millisecond
+		case "wtime":
+			clk[sideWhite] = time.Duration(n) * time.Millisecond
+		case "btime":
+			clk[sideBlack] = time.Duration(n) * time.Millisecond
+		case "winc":
+			inc[sideWhite] = time.Duration(n) * time.Millisecond
+		case "binc":
+			inc[sideBlack] = time.Duration(n) * time.Millisecond
+		case "movestogo":
+			mtg = int(n)
+		}
+	}
+
+	if sd := e.client.game.getCurrentSide(); limits.Time == 0 && clk[sd] > 0 && !infinite {
+		limits.Time = moveTime(clk[sd], inc[sd], mtg)
+	}
+
+	return limits, infinite
+}
+
+// moveTime returns the time to spend on a move, given the time remaining on
+// the clock, the increment and the number of moves until the next time
+// control (when known).
+func moveTime(rem, inc time.Duration, mtg int) time.Duration {
+	if mtg <= 0 {
+		mtg = defaultMovesToGo
+	}
+
+	t := rem/time.Duration(mtg) + inc*3/4
+
+	// never risk more than half of the time remaining
+	return max(min(t, rem/2), time.Millisecond)
+}
+
+// info reports the result of a completed iteration of the search.
+func (e *uciEngine) info(res *SearchResult) {
+	score := "cp " + strconv.Itoa(res.Score)
+	if res.Mate != 0 {
+		score = "mate " + strconv.Itoa(res.Mate)
+	}
+
+	nps := uint64(0)
+	if ms := res.Time.Milliseconds(); ms > 0 {
+		nps = res.Nodes * 1000 / uint64(ms)
+	}
+
+	e.send(
+		"info depth %d score %s nodes %d nps %d time %d pv %s",
+		res.Depth, score, res.Nodes, nps, res.Time.Milliseconds(), strings.Join(res.PV, " "))
+}
+
+// stop stops the search in progress, waiting for its best move to be reported.
+func (e *uciEngine) stop() {
+	if e.cancel == nil {
+		return
+	}
+
+	e.cancel()
+	<-e.done
+	e.cancel, e.done = nil, nil
+}
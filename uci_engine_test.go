@@ -0,0 +1,229 @@
+package chess
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// engineSession drives an engine through pipes, as a GUI would.
+type engineSession struct {
+	cmds chan string // cmds are written to the engine in order, without waiting for it to read them.
+	done chan error
+	out  *bufio.Scanner
+}
+
+// startUCIEngine runs a UCI engine until the test ends.
+func startUCIEngine(t *testing.T) *engineSession {
+	t.Helper()
+
+	return startEngine(t, func(r io.Reader, w io.Writer) error {
+		return CreateUCIEngine(r, w, UCIEngineOptions{Name: "test engine"}).Run(context.Background())
+	})
+}
+
+// startEngine runs an engine reading commands from r and writing to w until
+// the test ends.
+func startEngine(t *testing.T, run func(r io.Reader, w io.Writer) error) *engineSession {
+	t.Helper()
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+
+	s := &engineSession{cmds: make(chan string, 16), done: make(chan error, 1), out: bufio.NewScanner(outR)}
+	go func() {
+		s.done <- run(inR, outW)
+	}()
+
+	go func() {
+		defer inW.Close()
+		for cmd := range s.cmds {
+			if _, err := io.WriteString(inW, cmd+"\n"); err != nil {
+				return
+			}
+		}
+	}()
+
+	t.Cleanup(func() {
+		close(s.cmds)
+		outR.Close()
+		<-s.done
+	})
+
+	return s
+}
+
+// send queues commands for the engine.
+func (s *engineSession) send(cmds ...string) {
+	for _, cmd := range cmds {
+		s.cmds <- cmd
+	}
+}
+
+// expect reads lines from the engine up to and including the first line
+// beginning with prefix.
+func (s *engineSession) expect(t *testing.T, prefix string) []string {
+	t.Helper()
+
+	var lns []string
+	for s.out.Scan() {
+		lns = append(lns, s.out.Text())
+		if strings.HasPrefix(s.out.Text(), prefix) {
+			return lns
+		}
+	}
+
+	t.Fatalf("expected a line beginning %q, got %v", prefix, lns)
+	return nil
+}
+
+// bestMove returns the move of a "bestmove" line.
+func bestMove(ln string) string {
+	return strings.Fields(ln)[1]
+}
+
+func TestUCIEngineHandshake(t *testing.T) {
+	s := startUCIEngine(t)
+
+	s.send("uci")
+	lns := s.expect(t, "uciok")
+	if !slices.Contains(lns, "id name test engine") || !slices.Contains(lns, "id author brozeph") {
+		t.Fatalf("expected the engine to identify itself, got %v", lns)
+	}
+
+	s.send("isready")
+	s.expect(t, "readyok")
+}
+
+func TestUCIEngineSearch(t *testing.T) {
+	s := startUCIEngine(t)
+
+	s.send("ucinewgame", "position startpos moves e2e4 e7e5 g1f3", "go depth 3")
+	lns := s.expect(t, "bestmove")
+
+	for d, ln := range lns[:len(lns)-1] {
+		if !strings.HasPrefix(ln, "info depth "+string(rune('1'+d))+" score cp ") || !strings.Contains(ln, " pv ") {
+			t.Fatalf("expected info for depth %d, got %q", d+1, ln)
+		}
+	}
+
+	client := CreateAlgebraicGameClient()
+	for _, ntn := range []string{"e4", "e5", "Nf3"} {
+		mustMove(t, client, ntn)
+	}
+
+	if mv := bestMove(lns[len(lns)-1]); !slices.Contains(client.UCIMoves(), mv) {
+		t.Fatalf("expected a legal move for black, got %s", mv)
+	}
+}
+
+func TestUCIEngineMate(t *testing.T) {
+	s := startUCIEngine(t)
+
+	s.send("position fen 6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "go depth 4")
+	lns := s.expect(t, "bestmove")
+
+	if mv := bestMove(lns[len(lns)-1]); mv != "a1a8" {
+		t.Fatalf("expected a1a8, got %s", mv)
+	}
+
+	if !strings.Contains(lns[len(lns)-2], "score mate 1") {
+		t.Fatalf("expected a mate score, got %q", lns[len(lns)-2])
+	}
+}
+
+func TestUCIEngineStop(t *testing.T) {
+	s := startUCIEngine(t)
+
+	s.send("position startpos", "go infinite")
+	s.expect(t, "info depth 2")
+
+	// the engine answers while searching, and reports its move once stopped
+	s.send("isready")
+	s.expect(t, "readyok")
+
+	s.send("stop")
+	lns := s.expect(t, "bestmove")
+	if mv := bestMove(lns[len(lns)-1]); !slices.Contains(CreateAlgebraicGameClient().UCIMoves(), mv) {
+		t.Fatalf("expected a legal move, got %s", mv)
+	}
+}
+
+func TestUCIEngineClock(t *testing.T) {
+	s := startUCIEngine(t)
+
+	start := time.Now()
+	s.send("position startpos moves d2d4", "go wtime 100 btime 3000 winc 0 binc 0")
+	s.expect(t, "bestmove")
+
+	if took := time.Since(start); took > 2*time.Second {
+		t.Fatalf("expected the search to respect the clock, took %v", took)
+	}
+}
+
+func TestUCIEngineChess960(t *testing.T) {
+	s := startUCIEngine(t)
+
+	s.send("setoption name UCI_Chess960 value true")
+
+	// castling is written as the king taking its own rook
+	for _, mv := range []string{"b1a1", "b1e1"} {
+		s.send("position fen rk2r3/pppppppp/8/8/8/8/PPPPPPPP/RK2R3 w KQkq - 0 1 moves "+mv, "isready")
+		if lns := s.expect(t, "readyok"); len(lns) != 1 {
+			t.Fatalf("expected %s to be accepted, got %v", mv, lns)
+		}
+	}
+}
+
+func TestUCIEngineInvalidMove(t *testing.T) {
+	s := startUCIEngine(t)
+
+	s.send("position startpos moves e2e5", "isready")
+	if lns := s.expect(t, "readyok"); len(lns) != 2 || !strings.HasPrefix(lns[0], "info string invalid move") {
+		t.Fatalf("expected the invalid move to be reported, got %v", lns)
+	}
+}
+
+func TestUCIEngineQuit(t *testing.T) {
+	s := startUCIEngine(t)
+
+	s.send("go infinite", "quit")
+	go func() {
+		for s.out.Scan() {
+		}
+	}()
+
+	select {
+	case err := <-s.done:
+		s.done <- err
+		if err != nil {
+			t.Fatalf("expected the engine to quit cleanly, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the engine to quit")
+	}
+}
+
+func TestMoveTime(t *testing.T) {
+	tests := []struct {
+		rem, inc time.Duration
+		mtg      int
+		want     time.Duration
+	}{
+		{60 * time.Second, 0, 0, 2 * time.Second},
+		{60 * time.Second, 0, 10, 6 * time.Second},
+		{10 * time.Second, 2 * time.Second, 0, 10*time.Second/30 + 1500*time.Millisecond},
+		{100 * time.Millisecond, 5 * time.Second, 0, 50 * time.Millisecond},
+		{0, 0, 0, time.Millisecond},
+	}
+
+	for _, tc := range tests {
+		if got := moveTime(tc.rem, tc.inc, tc.mtg); got != tc.want {
+			t.Fatalf("moveTime(%v, %v, %d): expected %v, got %v", tc.rem, tc.inc, tc.mtg, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package chess
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestUCIMovesInitialPosition(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+
+	mvs := client.UCIMoves()
+	if len(mvs) != 20 {
+		t.Fatalf("expected 20 uci moves, got %d", len(mvs))
+	}
+
+	for _, mv := range []string{"e2e4", "g1f3", "b1a3", "h2h3"} {
+		if !slices.Contains(mvs, mv) {
+			t.Fatalf("expected %s in %v", mv, mvs)
+		}
+	}
+}
+
+func TestMoveUCI(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+
+	for _, mv := range []string{"e2e4", "e7e5", "g1f3", "b8c6", "f1c4", "g8f6", "e1g1"} {
+		if _, err := client.MoveUCI(mv); err != nil {
+			t.Fatalf("MoveUCI(%s) error = %v", mv, err)
+		}
+	}
+
+	last := client.game.MoveHistory[len(client.game.MoveHistory)-1]
+	if !last.Castle || last.Algebraic != "O-O" {
+		t.Fatalf("expected castle recorded as O-O, got %s", last.Algebraic)
+	}
+
+	for _, mv := range []string{"e2e4", "a7a4", "zz", "e7e5q"} {
+		if _, err := client.MoveUCI(mv); err == nil {
+			t.Fatalf("expected MoveUCI(%s) to fail", mv)
+		}
+	}
+}
+
+func TestMoveUCIPromotion(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN("1r2k3/2P5/8/8/8/8/8/4K3 w - - 0 1")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	mvs := client.UCIMoves()
+	for _, mv := range []string{"c7c8q", "c7c8r", "c7c8b", "c7c8n", "c7b8q", "c7b8n"} {
+		if !slices.Contains(mvs, mv) {
+			t.Fatalf("expected %s in %v", mv, mvs)
+		}
+	}
+	if slices.Contains(mvs, "c7c8") {
+		t.Fatalf("expected c7c8 to require a promotion piece")
+	}
+
+	res, err := client.MoveUCI("c7b8n")
+	if err != nil {
+		t.Fatalf("MoveUCI() error = %v", err)
+	}
+	if res.Move.PostSquare.Piece.Type != pieceKnight {
+		t.Fatalf("expected knight promotion, got %v", res.Move.PostSquare.Piece.Type)
+	}
+	if res.Move.Algebraic != "cxb8=N" {
+		t.Fatalf("expected cxb8=N, got %s", res.Move.Algebraic)
+	}
+}
+
+func TestVerbosePromotionNotationParses(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN("4k3/2P5/8/8/8/8/8/4K3 w - - 0 1")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	res := mustMove(t, client, "c7c8R")
+	if res.Move.PostSquare.Piece.Type != pieceRook {
+		t.Fatalf("expected rook promotion")
+	}
+	if res.Move.Algebraic != "c8=R+" {
+		t.Fatalf("expected c8=R+, got %s", res.Move.Algebraic)
+	}
+}
+
+func TestUCIAndSANConversion(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+
+	for _, mv := range []string{"f3", "e5", "g4"} {
+		mustMove(t, client, mv)
+	}
+
+	for _, tc := range []struct {
+		uci string
+		san string
+	}{
+		{"d8h4", "Qh4#"},
+		{"f8c5", "Bc5"},
+		{"b8c6", "Nc6"},
+		{"e5e4", "e4"},
+	} {
+		san, err := client.UCIToSAN(tc.uci)
+		if err != nil {
+			t.Fatalf("UCIToSAN(%s) error = %v", tc.uci, err)
+		}
+		if san != tc.san {
+			t.Fatalf("UCIToSAN(%s) = %s, want %s", tc.uci, san, tc.san)
+		}
+
+		uci, err := client.SANToUCI(tc.san)
+		if err != nil {
+			t.Fatalf("SANToUCI(%s) error = %v", tc.san, err)
+		}
+		if uci != tc.uci {
+			t.Fatalf("SANToUCI(%s) = %s, want %s", tc.san, uci, tc.uci)
+		}
+	}
+
+	if _, err := client.UCIToSAN("e2e4"); err == nil {
+		t.Fatalf("expected UCIToSAN to reject an illegal move")
+	}
+	if _, err := client.SANToUCI("Ke2"); err == nil {
+		t.Fatalf("expected SANToUCI to reject an illegal move")
+	}
+}
@@ -0,0 +1,95 @@
+package chess
+
+import "testing"
+
+// undoFidelityMoves covers castling on both wings, en passant, promotion with
+// capture and the loss of castling rights.
+var undoFidelityMoves = []string{
+	"e4", "d5", "e5", "f5", "exf6", "Nc6", "fxg7", "Bh3", "gxh8=Q", "Qd7",
+	"Nf3", "O-O-O", "Bc4", "dxc4", "O-O", "Bxg2", "Kxg2", "Qg4+", "Kh1", "Qxf3+",
+}
+
+func TestUndoRestoresFENAfterEveryMove(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{PGN: true})
+
+	for _, mv := range undoFidelityMoves {
+		before := client.FEN()
+
+		mustMove(t, client, mv).Undo()
+		if got := client.FEN(); got != before {
+			t.Fatalf("FEN after undoing %s:\n got: %s\nwant: %s", mv, got, before)
+		}
+
+		res := mustMove(t, client, mv)
+		after := client.FEN()
+
+		// the position must also survive a round trip through FEN
+		rt, err := CreateAlgebraicGameClientFromFEN(after)
+		if err != nil {
+			t.Fatalf("fromFEN(%s) failed: %v", after, err)
+		}
+		if got := rt.FEN(); got != after {
+			t.Fatalf("FEN round trip after %s:\n got: %s\nwant: %s", mv, got, after)
+		}
+
+		res.Undo()
+		if got := client.FEN(); got != before {
+			t.Fatalf("FEN after undoing %s again:\n got: %s\nwant: %s", mv, got, before)
+		}
+
+		mustMove(t, client, mv)
+	}
+}
+
+func TestUndoRestoresEnPassantCapture(t *testing.T) {
+	client := CreateAlgebraicGameClient(AlgebraicClientOptions{})
+
+	for _, mv := range []string{"e4", "a6", "e5", "d5"} {
+		mustMove(t, client, mv)
+	}
+
+	mustMove(t, client, "exd6").Undo()
+
+	if got := client.FEN(); got != "rnbqkbnr/1pp1pppp/p7/3pP3/8/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 3" {
+		t.Fatalf("unexpected FEN after undo: %s", got)
+	}
+	if _, ok := mustStatus(t, client, false).NotatedMoves["exd6"]; !ok {
+		t.Fatalf("expected en passant capture to remain available after undo")
+	}
+}
+
+func TestUndoRestoresCastlingRights(t *testing.T) {
+	client, err := CreateAlgebraicGameClientFromFEN("r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 5 20")
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	mustMove(t, client, "Rxa8+").Undo()
+	mustMove(t, client, "Kf1").Undo()
+
+	if got := client.FEN(); got != "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 5 20" {
+		t.Fatalf("unexpected FEN after undo: %s", got)
+	}
+
+	mustMove(t, client, "0-0-0")
+}
+
+func TestUndoRestoresPromotion(t *testing.T) {
+	fen := "1r2k3/2P5/8/8/8/8/8/4K3 w - - 3 40"
+	client, err := CreateAlgebraicGameClientFromFEN(fen)
+	if err != nil {
+		t.Fatalf("fromFEN failed: %v", err)
+	}
+
+	mustMove(t, client, "cxb8=Q+").Undo()
+
+	if got := client.FEN(); got != fen {
+		t.Fatalf("FEN mismatch:\n got: %s\nwant: %s", got, fen)
+	}
+	if len(client.CaptureHistory()) != 0 {
+		t.Fatalf("expected capture history to be restored")
+	}
+	if _, ok := mustStatus(t, client, false).NotatedMoves["c8=N"]; !ok {
+		t.Fatalf("expected promotion to remain available after undo")
+	}
+}
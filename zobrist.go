@@ -0,0 +1,65 @@
+package chess
+
+// zobristSeed seeds the generation of the Zobrist keys, so that the hash of
+// a position is the same in every program using the package.
+const zobristSeed = 0x9e3779b97f4a7c15
+
+var (
+	// zobristCastle contains a key for each side's right to castle with the rook of each file.
+	zobristCastle [2][8]uint64
+	// zobristEnPassant contains a key for the file of each en passant target.
+	zobristEnPassant [8]uint64
+	// zobristPieces contains a key for each piece (by pieceCode-1) on each square.
+	zobristPieces [12][64]uint64
+	// zobristSide is the key of positions with black to move.
+	zobristSide uint64
+)
+
+func init() {
+	// splitmix64 produces well distributed keys from a fixed seed
+	state := uint64(zobristSeed)
+	next := func() uint64 {
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		return z ^ (z >> 31)
+	}
+
+	for pc := range zobristPieces {
+		for sq := range zobristPieces[pc] {
+			zobristPieces[pc][sq] = next()
+		}
+	}
+
+	for sd := range zobristCastle {
+		for f := range zobristCastle[sd] {
+			zobristCastle[sd][f] = next()
+		}
+	}
+
+	for f := range zobristEnPassant {
+		zobristEnPassant[f] = next()
+	}
+
+	zobristSide = next()
+}
+
+// zobristPiece returns the key of a piece standing on a square of the board.
+func zobristPiece(p *Piece, sq *Square) uint64 {
+	return zobristPieces[codeOf(p.Type, p.Side)-1][(sq.Rank-1)*8+int(sq.File-'a')]
+}
+
+// zobristCastleRights returns the key of the castling rights.
+func zobristCastleRights(cr castleRights) uint64 {
+	var key uint64
+	for sd := range cr {
+		for _, rf := range cr[sd] {
+			if rf != 0 {
+				key ^= zobristCastle[sd][rf-'a']
+			}
+		}
+	}
+
+	return key
+}
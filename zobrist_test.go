@@ -0,0 +1,123 @@
+package chess
+
+import "testing"
+
+func TestHashMatchesPosition(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		c960  bool
+		moves []string
+	}{
+		{
+			name:  "castling and en passant",
+			fen:   "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			moves: []string{"e4", "Nf6", "e5", "d5", "exd6", "e6", "Nf3", "Be7", "Bb5+", "c6", "0-0", "0-0", "dxe7", "Qxe7", "Re1", "cxb5"},
+		},
+		{
+			name:  "promotion",
+			fen:   "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+			moves: []string{"Kh1", "bxa1=N", "Qxa1", "0-0-0"},
+		},
+		{
+			name:  "chess960",
+			fen:   "rk2r3/pppppppp/8/8/8/8/PPPPPPPP/RK2R3 w KQkq - 0 1",
+			c960:  true,
+			moves: []string{"0-0", "0-0-0", "Rfe1", "Kb8"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := AlgebraicClientOptions{Chess960: tc.c960}
+			client := mustFEN(t, tc.fen, opts)
+			hashes := []uint64{client.Hash()}
+
+			for _, mv := range tc.moves {
+				mustMove(t, client, mv)
+
+				// the incrementally updated key matches the key of the position loaded afresh
+				want := mustFEN(t, client.FEN(), opts).Hash()
+				if got := client.Hash(); got != want {
+					t.Fatalf("after %s: expected hash %x, got %x", mv, want, got)
+				}
+
+				if got := newPosition(client.game).Hash(); got != want {
+					t.Fatalf("after %s: expected position hash %x, got %x", mv, want, got)
+				}
+
+				hashes = append(hashes, client.Hash())
+			}
+
+			// undoing a move restores the key
+			for i := len(hashes) - 2; i >= 0; i-- {
+				if err := client.Undo(); err != nil {
+					t.Fatalf("undo failed: %v", err)
+				}
+
+				if got := client.Hash(); got != hashes[i] {
+					t.Fatalf("after undo to ply %d: expected hash %x, got %x", i, hashes[i], got)
+				}
+			}
+		})
+	}
+}
+
+func TestHashIdentity(t *testing.T) {
+	t.Run("transpositions", func(t *testing.T) {
+		a := CreateAlgebraicGameClient()
+		b := CreateAlgebraicGameClient()
+		for _, mv := range []string{"Nf3", "Nf6", "Nc3"} {
+			mustMove(t, a, mv)
+		}
+		for _, mv := range []string{"Nc3", "Nf6", "Nf3"} {
+			mustMove(t, b, mv)
+		}
+
+		if a.Hash() != b.Hash() {
+			t.Fatalf("expected transposed positions to share a hash")
+		}
+	})
+
+	t.Run("side to move", func(t *testing.T) {
+		w := mustFEN(t, "4k3/8/8/8/8/8/8/4K2R w - - 0 1")
+		b := mustFEN(t, "4k3/8/8/8/8/8/8/4K2R b - - 0 1")
+		if w.Hash() == b.Hash() {
+			t.Fatalf("expected the side to move to change the hash")
+		}
+	})
+
+	t.Run("castling rights", func(t *testing.T) {
+		a := mustFEN(t, "4k3/8/8/8/8/8/8/4K2R w K - 0 1")
+		b := mustFEN(t, "4k3/8/8/8/8/8/8/4K2R w - - 0 1")
+		if a.Hash() == b.Hash() {
+			t.Fatalf("expected castling rights to change the hash")
+		}
+	})
+
+	t.Run("en passant", func(t *testing.T) {
+		// no black pawn can capture on e3, so the target is not part of the identity
+		a := mustFEN(t, "4k3/8/8/8/4P3/8/8/4K3 b - e3 0 1")
+		b := mustFEN(t, "4k3/8/8/8/4P3/8/8/4K3 b - - 0 1")
+		if a.Hash() != b.Hash() {
+			t.Fatalf("expected an en passant target without a capture to be ignored")
+		}
+
+		c := mustFEN(t, "4k3/8/8/8/3pP3/8/8/4K3 b - e3 0 1")
+		d := mustFEN(t, "4k3/8/8/8/3pP3/8/8/4K3 b - - 0 1")
+		if c.Hash() == d.Hash() {
+			t.Fatalf("expected a possible en passant capture to change the hash")
+		}
+	})
+}
+
+func BenchmarkHash(b *testing.B) {
+	client := CreateAlgebraicGameClient()
+	if _, err := client.Move("e4"); err != nil {
+		b.Fatal(err)
+	}
+
+	for b.Loop() {
+		_ = client.Hash()
+	}
+}